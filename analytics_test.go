@@ -0,0 +1,48 @@
+package pagerduty
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_ListIncidentAnalytics_DistinguishesNullFromZero(t *testing.T) {
+	t.Parallel()
+
+	// given
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{
+			"id": "P1",
+			"seconds_to_first_ack": null,
+			"seconds_to_resolve": 0,
+			"seconds_to_engage": 12.5
+		}]}`))
+	}), ClientConfig{})
+
+	// when
+	result, err := client.ListIncidentAnalytics(context.Background(), IncidentAnalyticsOptions{})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Data) != 1 {
+		t.Fatalf("got %d incidents, want 1", len(result.Data))
+	}
+	incident := result.Data[0]
+
+	if incident.SecondsToFirstAck != nil {
+		t.Errorf("got SecondsToFirstAck %v for null, want nil", *incident.SecondsToFirstAck)
+	}
+	if incident.SecondsToMobilize != nil {
+		t.Errorf("got SecondsToMobilize %v for an absent field, want nil", *incident.SecondsToMobilize)
+	}
+	if incident.SecondsToResolve == nil || *incident.SecondsToResolve != 0 {
+		t.Errorf("got SecondsToResolve %v, want a non-nil zero", incident.SecondsToResolve)
+	}
+	if incident.SecondsToEngage == nil || incident.SecondsToEngage.Duration() != 12500*time.Millisecond {
+		t.Errorf("got SecondsToEngage %v, want 12.5s", incident.SecondsToEngage)
+	}
+}
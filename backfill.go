@@ -0,0 +1,217 @@
+package pagerduty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	transform "github.com/resolute-sh/resolute-transform"
+	"github.com/resolute-sh/resolute/core"
+)
+
+const (
+	defaultBackfillWindow       = 24 * time.Hour
+	defaultBackfillMaxIncidents = 5000
+	backfillPageSize            = 100
+
+	// backfillClockSkew widens a recheck tail to cover clock differences
+	// between the worker and PagerDuty.
+	backfillClockSkew = time.Minute
+)
+
+// BackfillCheckpoint records where a backfill run stopped. It holds only
+// plain values so it can be passed unchanged into the next run, including
+// across a workflow's continue-as-new.
+type BackfillCheckpoint struct {
+	// WindowStart is the start of the window being fetched.
+	WindowStart time.Time
+	// Offset is the number of incidents already fetched from that window.
+	Offset int
+
+	// PassStartedAt and Total are recorded when RecheckTotal is set: when
+	// the current pass over the range began, and how many incidents the
+	// range held at that moment.
+	PassStartedAt time.Time
+	Total         int
+}
+
+// BackfillIncidentsInput is the input for BackfillIncidentsActivity.
+type BackfillIncidentsInput struct {
+	APIKey    string
+	SecretRef string
+	Since     time.Time
+	Until     time.Time
+	Statuses  []string
+
+	// Window is the time span fetched per query, keeping each query well
+	// under PagerDuty's pagination limits. Defaults to 24 hours.
+	Window time.Duration
+
+	// MaxIncidents bounds how many incidents one run fetches. Defaults to
+	// 5000.
+	MaxIncidents int
+
+	// Checkpoint resumes a previous run. Leave nil to start at Since.
+	Checkpoint *BackfillCheckpoint
+
+	// RecheckTotal counts the range again once it is exhausted. If the
+	// count grew by more than RecheckThreshold, incidents created since the
+	// pass began are fetched again, until the count settles. With a zero
+	// threshold every incident in the range that existed at the final
+	// recheck has then been fetched at least once; some may be fetched
+	// twice. It cannot be combined with Statuses, since incidents changing
+	// status move in and out of the count regardless of when they were
+	// created.
+	RecheckTotal     bool
+	RecheckThreshold int
+
+	// Priority orders this activity's requests against other activities
+	// sharing a PriorityRateLimiter set with SetRateLimiter.
+	Priority int
+}
+
+// BackfillIncidentsOutput is the output of BackfillIncidentsActivity.
+type BackfillIncidentsOutput struct {
+	Ref   core.DataRef
+	Count int
+
+	// Checkpoint is where the next run should resume. It is only
+	// meaningful when Done is false.
+	Checkpoint BackfillCheckpoint
+	Done       bool
+}
+
+// BackfillIncidentsActivity fetches incidents between Since and Until in
+// bounded runs, each resuming from the previous run's checkpoint. See the
+// package documentation for driving it from a workflow.
+func BackfillIncidentsActivity(ctx context.Context, input BackfillIncidentsInput) (BackfillIncidentsOutput, error) {
+	if !input.Until.After(input.Since) {
+		return BackfillIncidentsOutput{}, errors.New("until must be after since")
+	}
+	if input.RecheckTotal && len(input.Statuses) > 0 {
+		return BackfillIncidentsOutput{}, errors.New("RecheckTotal and Statuses are mutually exclusive")
+	}
+
+	window := input.Window
+	if window <= 0 {
+		window = defaultBackfillWindow
+	}
+
+	maxIncidents := input.MaxIncidents
+	if maxIncidents <= 0 {
+		maxIncidents = defaultBackfillMaxIncidents
+	}
+
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey:   input.APIKey,
+		Priority: input.Priority,
+	})
+	if err != nil {
+		return BackfillIncidentsOutput{}, err
+	}
+
+	checkpoint := BackfillCheckpoint{WindowStart: input.Since}
+	if input.Checkpoint != nil {
+		checkpoint = *input.Checkpoint
+	} else if input.RecheckTotal {
+		checkpoint.PassStartedAt = time.Now()
+		total, err := countBackfillIncidents(ctx, client, input)
+		if err != nil {
+			return BackfillIncidentsOutput{}, err
+		}
+		checkpoint.Total = total
+	}
+
+	var docs []transform.Document
+	for checkpoint.WindowStart.Before(input.Until) && len(docs) < maxIncidents {
+		windowEnd := checkpoint.WindowStart.Add(window)
+		if windowEnd.After(input.Until) {
+			windowEnd = input.Until
+		}
+
+		limit := backfillPageSize
+		if remaining := maxIncidents - len(docs); remaining < limit {
+			limit = remaining
+		}
+
+		page, err := client.ListIncidentsWithOptions(ctx, ListIncidentsOptions{
+			Since:    &checkpoint.WindowStart,
+			Until:    &windowEnd,
+			Limit:    limit,
+			Offset:   checkpoint.Offset,
+			Statuses: input.Statuses,
+		})
+		if err != nil {
+			return BackfillIncidentsOutput{}, fmt.Errorf("list incidents from %s: %w", checkpoint.WindowStart.Format(time.RFC3339), err)
+		}
+
+		sortIncidents(page.Incidents)
+		for _, incident := range page.Incidents {
+			docs = append(docs, incidentToDocument(incident))
+		}
+
+		if page.More && len(page.Incidents) > 0 {
+			checkpoint.Offset += len(page.Incidents)
+			continue
+		}
+
+		checkpoint.WindowStart, checkpoint.Offset = windowEnd, 0
+	}
+
+	if input.RecheckTotal && !checkpoint.WindowStart.Before(input.Until) {
+		total, err := countBackfillIncidents(ctx, client, input)
+		if err != nil {
+			return BackfillIncidentsOutput{}, err
+		}
+
+		if total-checkpoint.Total > input.RecheckThreshold {
+			tailStart := checkpoint.PassStartedAt.Add(-backfillClockSkew)
+			if tailStart.Before(input.Since) {
+				tailStart = input.Since
+			}
+			checkpoint = BackfillCheckpoint{
+				WindowStart:   tailStart,
+				PassStartedAt: time.Now(),
+				Total:         total,
+			}
+		}
+	}
+
+	if err := postProcessDocuments(ctx, docs); err != nil {
+		return BackfillIncidentsOutput{}, err
+	}
+
+	ref, err := transform.StoreDocuments(ctx, docs)
+	if err != nil {
+		return BackfillIncidentsOutput{}, fmt.Errorf("store documents: %w", err)
+	}
+
+	return BackfillIncidentsOutput{
+		Ref:        ref,
+		Count:      len(docs),
+		Checkpoint: checkpoint,
+		Done:       !checkpoint.WindowStart.Before(input.Until),
+	}, nil
+}
+
+// countBackfillIncidents returns how many incidents the backfill range
+// currently holds.
+func countBackfillIncidents(ctx context.Context, client *Client, input BackfillIncidentsInput) (int, error) {
+	result, err := client.ListIncidentsWithOptions(ctx, ListIncidentsOptions{
+		Since:    &input.Since,
+		Until:    &input.Until,
+		Limit:    1,
+		Statuses: input.Statuses,
+		Total:    true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("count incidents: %w", err)
+	}
+	return result.Total, nil
+}
+
+// BackfillIncidents creates a node for one run of a PagerDuty incident backfill.
+func BackfillIncidents(input BackfillIncidentsInput) *core.Node[BackfillIncidentsInput, BackfillIncidentsOutput] {
+	return core.NewNode("pagerduty.BackfillIncidents", BackfillIncidentsActivity, input)
+}
@@ -0,0 +1,254 @@
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// incidentRange is an incidents API stub that pages through its incidents
+// in creation order, honoring the since, until, offset, limit and total
+// parameters the way PagerDuty does.
+type incidentRange struct {
+	t         *testing.T
+	mu        sync.Mutex
+	incidents []Incident
+}
+
+func (s *incidentRange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	since, err := time.Parse(time.RFC3339, query.Get("since"))
+	if err != nil {
+		s.t.Errorf("parse since: %v", err)
+	}
+	until, err := time.Parse(time.RFC3339, query.Get("until"))
+	if err != nil {
+		s.t.Errorf("parse until: %v", err)
+	}
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	s.mu.Lock()
+	var matched []Incident
+	for _, incident := range s.incidents {
+		if !incident.CreatedAt.Before(since) && incident.CreatedAt.Before(until) {
+			matched = append(matched, incident)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+	})
+
+	resp := IncidentListResponse{Limit: limit, Offset: offset}
+	if query.Get("total") == "true" {
+		resp.Total = len(matched)
+	}
+	if offset < len(matched) {
+		end := offset + limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		resp.Incidents = matched[offset:end]
+		resp.More = end < len(matched)
+	}
+	writeJSON(s.t, w, resp)
+}
+
+// add appends incidents to the range.
+func (s *incidentRange) add(incidents ...Incident) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.incidents = append(s.incidents, incidents...)
+}
+
+// runBackfill drives BackfillIncidentsActivity until it is done, passing
+// each checkpoint through JSON the way continue-as-new does, and returns
+// the IDs of the documents each run stored.
+func runBackfill(t *testing.T, input BackfillIncidentsInput) [][]string {
+	t.Helper()
+
+	var runs [][]string
+	for {
+		if len(runs) == 20 {
+			t.Fatal("backfill did not finish after 20 runs")
+		}
+
+		data, err := json.Marshal(input)
+		if err != nil {
+			t.Fatalf("marshal input: %v", err)
+		}
+		var resumed BackfillIncidentsInput
+		if err := json.Unmarshal(data, &resumed); err != nil {
+			t.Fatalf("unmarshal input: %v", err)
+		}
+
+		output, err := BackfillIncidentsActivity(context.Background(), resumed)
+		if err != nil {
+			t.Fatalf("run %d: unexpected error: %v", len(runs)+1, err)
+		}
+
+		var ids []string
+		for _, doc := range loadDocs(t, output.Ref) {
+			ids = append(ids, doc.ID)
+		}
+		runs = append(runs, ids)
+
+		if output.Done {
+			return runs
+		}
+		input.Checkpoint = &output.Checkpoint
+	}
+}
+
+func TestBackfillIncidentsActivity_ResumesFromRoundTrippedCheckpoint(t *testing.T) {
+	// given
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := &incidentRange{t: t}
+	for i, offset := range []time.Duration{1 * time.Hour, 5 * time.Hour, 26 * time.Hour, 30 * time.Hour, 50 * time.Hour} {
+		server.add(Incident{
+			ID:             fmt.Sprintf("P%d", i+1),
+			IncidentNumber: i + 1,
+			CreatedAt:      since.Add(offset),
+		})
+	}
+	useTestAPI(t, server)
+
+	// when
+	runs := runBackfill(t, BackfillIncidentsInput{
+		Since:        since,
+		Until:        since.Add(72 * time.Hour),
+		Window:       24 * time.Hour,
+		MaxIncidents: 2,
+	})
+
+	// then
+	if len(runs) < 2 {
+		t.Fatalf("got %d runs, want the backfill split across several", len(runs))
+	}
+
+	seen := make(map[string]int)
+	for _, ids := range runs {
+		if len(ids) > 2 {
+			t.Errorf("run fetched %d incidents, want at most 2", len(ids))
+		}
+		for _, id := range ids {
+			seen[id]++
+		}
+	}
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("P%d", i)
+		if seen[id] != 1 {
+			t.Errorf("incident %s fetched %d times, want 1", id, seen[id])
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("got %d distinct incidents, want 5", len(seen))
+	}
+}
+
+func TestBackfillIncidentsActivity_RechecksIncidentsCreatedDuringBackfill(t *testing.T) {
+	// given
+	now := time.Now().UTC()
+	server := &incidentRange{t: t}
+	server.add(
+		Incident{ID: "P1", IncidentNumber: 1, CreatedAt: now.Add(-90 * time.Minute)},
+		Incident{ID: "P2", IncidentNumber: 2, CreatedAt: now.Add(-90 * time.Minute)},
+	)
+
+	var grow sync.Once
+	useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.ServeHTTP(w, r)
+		if r.URL.Query().Get("total") != "true" {
+			grow.Do(func() {
+				server.add(Incident{ID: "P3", IncidentNumber: 3, CreatedAt: time.Now().UTC()})
+			})
+		}
+	}))
+
+	// when
+	runs := runBackfill(t, BackfillIncidentsInput{
+		Since:        now.Add(-2 * time.Hour),
+		Until:        now.Add(time.Hour),
+		RecheckTotal: true,
+	})
+
+	// then
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	seen := make(map[string]bool)
+	for _, ids := range runs {
+		for _, id := range ids {
+			seen[id] = true
+		}
+	}
+	for _, id := range []string{"P1", "P2", "P3"} {
+		if !seen[id] {
+			t.Errorf("incident %s was never fetched", id)
+		}
+	}
+	if len(runs[1]) != 1 || runs[1][0] != "P3" {
+		t.Errorf("recheck run fetched %v, want only [P3]", runs[1])
+	}
+}
+
+func TestBackfillIncidentsActivity_RejectsRecheckWithStatuses(t *testing.T) {
+	t.Parallel()
+
+	// given
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// when
+	_, err := BackfillIncidentsActivity(context.Background(), BackfillIncidentsInput{
+		Since:        since,
+		Until:        since.Add(time.Hour),
+		Statuses:     []string{StatusResolved},
+		RecheckTotal: true,
+	})
+
+	// then
+	if err == nil {
+		t.Fatal("expected an error combining RecheckTotal with Statuses")
+	}
+}
+
+func TestBackfillIncidentsActivity_OrdersSameTimestampByNumber(t *testing.T) {
+	// given
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := since.Add(time.Hour)
+	server := &incidentRange{t: t}
+	server.add(
+		Incident{ID: "P3", IncidentNumber: 3, CreatedAt: created},
+		Incident{ID: "P1", IncidentNumber: 1, CreatedAt: created},
+		Incident{ID: "P2", IncidentNumber: 2, CreatedAt: created},
+	)
+	useTestAPI(t, server)
+
+	// when
+	output, err := BackfillIncidentsActivity(context.Background(), BackfillIncidentsInput{
+		Since: since,
+		Until: since.Add(24 * time.Hour),
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	docs := loadDocs(t, output.Ref)
+	if len(docs) != 3 {
+		t.Fatalf("got %d documents, want 3", len(docs))
+	}
+	for i, want := range []string{"P1", "P2", "P3"} {
+		if docs[i].ID != want {
+			t.Errorf("got %s at position %d, want %s", docs[i].ID, i, want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package pagerduty
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// IncidentFailure records an incident a batch operation failed on.
+type IncidentFailure struct {
+	IncidentID string
+	Error      string
+}
+
+// runBounded calls fn for each index in [0, n) with at most concurrency calls
+// in flight, and returns once all calls have finished.
+func runBounded(n, concurrency int, fn func(i int)) {
+	if concurrency <= 0 {
+		concurrency = defaultEnrichConcurrency
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// failureBudget counts failed sub-operations against a limit. A zero limit
+// never runs out.
+type failureBudget struct {
+	max      int
+	failures atomic.Int64
+}
+
+// fail records a failure.
+func (b *failureBudget) fail() {
+	b.failures.Add(1)
+}
+
+// exceeded reports whether more failures than allowed have been recorded.
+func (b *failureBudget) exceeded() bool {
+	return b.max > 0 && int(b.failures.Load()) > b.max
+}
@@ -0,0 +1,137 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// DefaultChatSummaryTemplate is the text/template used by
+// RenderIncidentSummaryActivity when no template is given.
+const DefaultChatSummaryTemplate = `[{{.Status}}] {{.Title}}
+Urgency: {{.Urgency}} | Assignee: {{if .Assignee}}{{.Assignee}}{{else}}unassigned{{end}} | {{if .Resolved}}Resolved after{{else}}Open for{{end}} {{.Duration}}
+{{.URL}}`
+
+// ChatSummary is the data available to chat summary templates.
+type ChatSummary struct {
+	ID       string
+	Title    string
+	Status   string
+	Urgency  string
+	Service  string
+	Assignee string
+	URL      string
+	Resolved bool
+
+	// Duration is the time from creation to resolution, or to now for
+	// incidents that are still open, rounded to the second.
+	Duration time.Duration
+}
+
+// RenderIncidentSummaryInput is the input for RenderIncidentSummaryActivity.
+type RenderIncidentSummaryInput struct {
+	APIKey     string
+	SecretRef  string
+	IncidentID string
+
+	// Template is a text/template executed with a ChatSummary. Defaults to
+	// DefaultChatSummaryTemplate.
+	Template string
+
+	// MaxRetryAfter fails fast with ErrRateLimited when PagerDuty asks to
+	// wait longer than this. Zero means no cap.
+	MaxRetryAfter time.Duration
+
+	// Priority orders this activity's requests against other activities
+	// sharing a PriorityRateLimiter set with SetRateLimiter.
+	Priority int
+}
+
+// RenderIncidentSummaryOutput is the output of RenderIncidentSummaryActivity.
+type RenderIncidentSummaryOutput struct {
+	Summary string
+	Found   bool
+}
+
+// RenderIncidentSummaryActivity fetches an incident and renders a compact,
+// platform-agnostic summary suitable for a Slack or Teams message. Unknown
+// incidents return Found false rather than an error.
+func RenderIncidentSummaryActivity(ctx context.Context, input RenderIncidentSummaryInput) (RenderIncidentSummaryOutput, error) {
+	text := input.Template
+	if text == "" {
+		text = DefaultChatSummaryTemplate
+	}
+
+	tmpl, err := template.New("summary").Parse(text)
+	if err != nil {
+		return RenderIncidentSummaryOutput{}, fmt.Errorf("parse template: %w", err)
+	}
+
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey:        input.APIKey,
+		MaxRetryAfter: input.MaxRetryAfter,
+		Priority:      input.Priority,
+	})
+	if err != nil {
+		return RenderIncidentSummaryOutput{}, err
+	}
+
+	incident, err := client.GetIncident(ctx, input.IncidentID, IncludeAssignees)
+	if IsNotFound(err) {
+		return RenderIncidentSummaryOutput{}, nil
+	}
+	if err != nil {
+		return RenderIncidentSummaryOutput{}, fmt.Errorf("get incident: %w", err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, chatSummary(*incident, time.Now())); err != nil {
+		return RenderIncidentSummaryOutput{}, fmt.Errorf("render summary: %w", err)
+	}
+
+	return RenderIncidentSummaryOutput{
+		Summary: sb.String(),
+		Found:   true,
+	}, nil
+}
+
+// chatSummary builds the template data for an incident as of now.
+func chatSummary(incident Incident, now time.Time) ChatSummary {
+	summary := ChatSummary{
+		ID:       incident.ID,
+		Title:    incident.Summary,
+		Status:   incident.Status,
+		Urgency:  incident.Urgency,
+		URL:      incident.HTMLURL,
+		Resolved: incident.ResolvedAt != nil,
+	}
+
+	summary.Service = incident.Service.Name
+	if summary.Service == "" {
+		summary.Service = incident.Service.Summary
+	}
+
+	if len(incident.Assignments) > 0 {
+		summary.Assignee = incident.Assignments[0].Assignee.DisplayName()
+	}
+
+	end := now
+	if incident.ResolvedAt != nil {
+		end = *incident.ResolvedAt
+	}
+	if d := end.Sub(incident.CreatedAt); d > 0 {
+		summary.Duration = d.Round(time.Second)
+	}
+
+	return summary
+}
+
+// RenderIncidentSummary creates a node for rendering a chat-ready incident
+// summary.
+func RenderIncidentSummary(input RenderIncidentSummaryInput) *core.Node[RenderIncidentSummaryInput, RenderIncidentSummaryOutput] {
+	return core.NewNode("pagerduty.RenderIncidentSummary", RenderIncidentSummaryActivity, input)
+}
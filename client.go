@@ -1,27 +1,107 @@
 package pagerduty
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+const (
+	baseURL = "https://api.pagerduty.com"
+
+	maxRetries     = 3
+	baseRetryDelay = time.Second
 )
 
-const baseURL = "https://api.pagerduty.com"
+// ErrRateLimited is returned when PagerDuty keeps rate limiting a request, or
+// asks the client to wait longer than its configured MaxRetryAfter.
+var ErrRateLimited = errors.New("pagerduty: rate limited")
+
+// APIError is returned when the PagerDuty API responds with a non-2xx status.
+type APIError struct {
+	Status int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("pagerduty API error: status=%d body=%s", e.Status, e.Body)
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e *APIError) StatusCode() int {
+	return e.Status
+}
+
+// IsNotFound reports whether err is an APIError with a 404 status.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
 
 // Client is a PagerDuty REST API client.
 type Client struct {
-	apiKey     string
-	httpClient *http.Client
+	baseURL       string
+	apiKey        string
+	from          string
+	httpClient    *http.Client
+	rateLimiter   core.RateLimiter
+	priority      int
+	maxRetryAfter time.Duration
+	timeZone      string
+	location      *time.Location
+	logger        *slog.Logger
+
+	// configErr is a configuration error found by NewClient, returned by
+	// every request instead of sending it.
+	configErr error
 }
 
 // ClientConfig contains configuration for creating a PagerDuty client.
 type ClientConfig struct {
 	APIKey  string
 	Timeout time.Duration
+
+	// BaseURL overrides the REST API endpoint, e.g. for PagerDuty's EU
+	// service region. Defaults to https://api.pagerduty.com.
+	BaseURL string
+
+	// From is the email of the PagerDuty user on whose behalf write
+	// requests (such as adding notes) are made.
+	From string
+
+	// RateLimiter, if set, is waited on before every request attempt.
+	RateLimiter core.RateLimiter
+
+	// Priority orders this client's requests against others sharing a
+	// PriorityRateLimiter. It has no effect with other limiters.
+	Priority int
+
+	// TimeZone is an IANA time zone name. Query times such as since and
+	// until are sent in this zone, and PagerDuty renders response times in
+	// it. By default query times are converted to UTC, so results do not
+	// depend on the zone of the time.Time values passed in. With an unknown
+	// zone every request fails rather than silently using UTC.
+	TimeZone string
+
+	// MaxRetryAfter caps how long the client will wait when rate limited.
+	// If PagerDuty asks for a longer wait, ErrRateLimited is returned
+	// immediately instead. Zero means no cap.
+	MaxRetryAfter time.Duration
+
+	// Logger, if set, receives a warning each time a request is retried
+	// after a 429 or 5xx response.
+	Logger *slog.Logger
 }
 
 // NewClient creates a new PagerDuty client.
@@ -31,11 +111,37 @@ func NewClient(cfg ClientConfig) *Client {
 		timeout = 30 * time.Second
 	}
 
+	location := time.UTC
+	timeZone := ""
+	var configErr error
+	if cfg.TimeZone != "" {
+		loc, err := time.LoadLocation(cfg.TimeZone)
+		if err != nil {
+			configErr = fmt.Errorf("load time zone %q: %w", cfg.TimeZone, err)
+		} else {
+			location, timeZone = loc, cfg.TimeZone
+		}
+	}
+
+	endpoint := cfg.BaseURL
+	if endpoint == "" {
+		endpoint = baseURL
+	}
+
 	return &Client{
-		apiKey: cfg.APIKey,
+		baseURL: strings.TrimSuffix(endpoint, "/"),
+		apiKey:  cfg.APIKey,
+		from:    cfg.From,
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
+		rateLimiter:   cfg.RateLimiter,
+		priority:      cfg.Priority,
+		maxRetryAfter: cfg.MaxRetryAfter,
+		timeZone:      timeZone,
+		location:      location,
+		logger:        cfg.Logger,
+		configErr:     configErr,
 	}
 }
 
@@ -43,6 +149,7 @@ func NewClient(cfg ClientConfig) *Client {
 type Incident struct {
 	ID               string           `json:"id"`
 	Type             string           `json:"type"`
+	IncidentNumber   int              `json:"incident_number"`
 	Summary          string           `json:"summary"`
 	Description      string           `json:"description"`
 	Status           string           `json:"status"`
@@ -54,7 +161,36 @@ type Incident struct {
 	Service          Service          `json:"service"`
 	Assignments      []Assignment     `json:"assignments"`
 	EscalationPolicy EscalationPolicy `json:"escalation_policy"`
+	Teams            []Team           `json:"teams"`
+	Body             *IncidentBody    `json:"body"`
+	Responders       []Responder      `json:"incidents_responders"`
 	HTMLURL          string           `json:"html_url"`
+
+	// Self is the incident's REST API URL.
+	Self string `json:"self"`
+}
+
+// Responder states.
+const (
+	ResponderStatePending  = "pending"
+	ResponderStateJoined   = "joined"
+	ResponderStateDeclined = "declined"
+)
+
+// Responder is a user asked to help with an incident through a responder
+// request.
+type Responder struct {
+	State       string    `json:"state"`
+	User        Agent     `json:"user"`
+	Requester   *Agent    `json:"requester"`
+	RequestedAt time.Time `json:"requested_at"`
+	Message     string    `json:"message"`
+}
+
+// IncidentBody holds the details an incident was created with.
+type IncidentBody struct {
+	Type    string `json:"type"`
+	Details string `json:"details"`
 }
 
 // Priority represents incident priority.
@@ -65,10 +201,14 @@ type Priority struct {
 }
 
 // Service represents a PagerDuty service.
+// Status is only populated when the service is included in full via
+// include[]=services; service references leave it empty.
 type Service struct {
 	ID      string `json:"id"`
+	Type    string `json:"type"`
 	Name    string `json:"name"`
 	Summary string `json:"summary"`
+	Status  string `json:"status"`
 }
 
 // Assignment represents an incident assignment.
@@ -77,13 +217,25 @@ type Assignment struct {
 	Assignee Assignee  `json:"assignee"`
 }
 
-// Assignee represents an assigned user.
+// Assignee represents an assigned user. Name and Email are only populated
+// when assignees are included in full via include[]=assignees.
 type Assignee struct {
 	ID      string `json:"id"`
+	Type    string `json:"type"`
 	Name    string `json:"name"`
+	Email   string `json:"email"`
 	Summary string `json:"summary"`
 }
 
+// DisplayName returns the assignee's name, falling back to the reference
+// summary when the assignee was not included in full.
+func (a Assignee) DisplayName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Summary
+}
+
 // EscalationPolicy represents an escalation policy.
 type EscalationPolicy struct {
 	ID      string `json:"id"`
@@ -91,6 +243,13 @@ type EscalationPolicy struct {
 	Summary string `json:"summary"`
 }
 
+// Team represents a PagerDuty team.
+type Team struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+}
+
 // Postmortem represents a PagerDuty postmortem.
 type Postmortem struct {
 	ID          string    `json:"id"`
@@ -111,8 +270,67 @@ type IncidentListResponse struct {
 	More      bool       `json:"more"`
 }
 
+// Incident statuses.
+const (
+	StatusTriggered    = "triggered"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+)
+
+// Normalized statuses reported by OpenClosedStatus.
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+// OpenClosedStatus is a StatusNormalizer mapping resolved incidents to
+// "closed" and every other status to "open".
+func OpenClosedStatus(status string) string {
+	if status == StatusResolved {
+		return StatusClosed
+	}
+	return StatusOpen
+}
+
+// Include values for expanding references in incident responses.
+const (
+	IncludeServices  = "services"
+	IncludeAssignees = "assignees"
+)
+
+// ListIncidentsOptions filters and pages an incident listing.
+type ListIncidentsOptions struct {
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+	Offset     int
+	Statuses   []string
+	Urgencies  []string
+	ServiceIDs []string
+	TeamIDs    []string
+	Include    []string
+
+	// Total requests that the response include the total number of
+	// matching incidents, which PagerDuty omits by default.
+	Total bool
+
+	// AllDates lists incidents from any date, ignoring Since and Until.
+	AllDates bool
+}
+
 // ListIncidents fetches incidents.
-func (c *Client) ListIncidents(ctx context.Context, since *time.Time, until *time.Time, limit int) (*IncidentListResponse, error) {
+func (c *Client) ListIncidents(ctx context.Context, since *time.Time, until *time.Time, limit int, include ...string) (*IncidentListResponse, error) {
+	return c.ListIncidentsWithOptions(ctx, ListIncidentsOptions{
+		Since:   since,
+		Until:   until,
+		Limit:   limit,
+		Include: include,
+	})
+}
+
+// ListIncidentsWithOptions fetches a page of incidents matching opts.
+func (c *Client) ListIncidentsWithOptions(ctx context.Context, opts ListIncidentsOptions) (*IncidentListResponse, error) {
+	limit := opts.Limit
 	if limit <= 0 {
 		limit = 25
 	}
@@ -120,46 +338,479 @@ func (c *Client) ListIncidents(ctx context.Context, since *time.Time, until *tim
 	params := url.Values{}
 	params.Set("limit", fmt.Sprintf("%d", limit))
 
-	if since != nil {
-		params.Set("since", since.Format(time.RFC3339))
+	if opts.Offset > 0 {
+		params.Set("offset", fmt.Sprintf("%d", opts.Offset))
+	}
+	if opts.Since != nil {
+		params.Set("since", c.formatTime(*opts.Since))
+	}
+	if opts.Until != nil {
+		params.Set("until", c.formatTime(*opts.Until))
+	}
+	if c.timeZone != "" {
+		params.Set("time_zone", c.timeZone)
+	}
+	if opts.Total {
+		params.Set("total", "true")
+	}
+	if opts.AllDates {
+		params.Set("date_range", "all")
 	}
-	if until != nil {
-		params.Set("until", until.Format(time.RFC3339))
+	for _, status := range opts.Statuses {
+		params.Add("statuses[]", status)
+	}
+	for _, urgency := range opts.Urgencies {
+		params.Add("urgencies[]", urgency)
+	}
+	for _, id := range opts.ServiceIDs {
+		params.Add("service_ids[]", id)
+	}
+	for _, id := range opts.TeamIDs {
+		params.Add("team_ids[]", id)
+	}
+	for _, inc := range opts.Include {
+		params.Add("include[]", inc)
 	}
 
-	endpoint := fmt.Sprintf("%s/incidents?%s", baseURL, params.Encode())
+	endpoint := fmt.Sprintf("%s/incidents?%s", c.baseURL, params.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+	var result IncidentListResponse
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
 	}
 
-	c.setAuth(req)
+	for i := range result.Incidents {
+		c.setIncidentSelf(&result.Incidents[i])
+	}
+	return &result, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+// IncidentUpdate is a change to apply to an incident with ManageIncidents.
+type IncidentUpdate struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Status     string `json:"status,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// ManageIncidents applies updates to several incidents in one request.
+// PagerDuty accepts at most 250 updates per request. The client must be
+// configured with a From address.
+func (c *Client) ManageIncidents(ctx context.Context, updates []IncidentUpdate) ([]Incident, error) {
+	endpoint := fmt.Sprintf("%s/incidents", c.baseURL)
+
+	for i := range updates {
+		if updates[i].Type == "" {
+			updates[i].Type = "incident_reference"
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("pagerduty API error: status=%d body=%s", resp.StatusCode, string(body))
+	body := map[string]interface{}{
+		"incidents": updates,
 	}
 
-	var result IncidentListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	var result struct {
+		Incidents []Incident `json:"incidents"`
+	}
+	if err := c.do(ctx, http.MethodPut, endpoint, body, &result); err != nil {
+		return nil, err
 	}
 
-	return &result, nil
+	for i := range result.Incidents {
+		c.setIncidentSelf(&result.Incidents[i])
+	}
+	return result.Incidents, nil
 }
 
 // GetIncident fetches a single incident by ID.
-func (c *Client) GetIncident(ctx context.Context, incidentID string) (*Incident, error) {
-	endpoint := fmt.Sprintf("%s/incidents/%s", baseURL, incidentID)
+func (c *Client) GetIncident(ctx context.Context, incidentID string, include ...string) (*Incident, error) {
+	endpoint := fmt.Sprintf("%s/incidents/%s", c.baseURL, incidentID)
+	if len(include) > 0 {
+		params := url.Values{}
+		for _, inc := range include {
+			params.Add("include[]", inc)
+		}
+		endpoint += "?" + params.Encode()
+	}
+
+	var result struct {
+		Incident Incident `json:"incident"`
+	}
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	c.setIncidentSelf(&result.Incident)
+	return &result.Incident, nil
+}
+
+// setIncidentSelf fills in the API URL of an incident PagerDuty returned
+// without one, using the client's endpoint.
+func (c *Client) setIncidentSelf(incident *Incident) {
+	if incident.Self == "" && incident.ID != "" {
+		incident.Self = fmt.Sprintf("%s/incidents/%s", c.baseURL, incident.ID)
+	}
+}
+
+// GetService fetches a single service by ID.
+func (c *Client) GetService(ctx context.Context, serviceID string) (*Service, error) {
+	endpoint := fmt.Sprintf("%s/services/%s", c.baseURL, serviceID)
+
+	var result struct {
+		Service Service `json:"service"`
+	}
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Service, nil
+}
+
+// ServiceListResponse represents the response from listing services.
+type ServiceListResponse struct {
+	Services []Service `json:"services"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+	More     bool      `json:"more"`
+}
+
+// ListServices fetches a page of services, optionally scoped to teams.
+func (c *Client) ListServices(ctx context.Context, teamIDs []string, limit, offset int) (*ServiceListResponse, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if offset > 0 {
+		params.Set("offset", fmt.Sprintf("%d", offset))
+	}
+	for _, id := range teamIDs {
+		params.Add("team_ids[]", id)
+	}
+
+	endpoint := fmt.Sprintf("%s/services?%s", c.baseURL, params.Encode())
+
+	var result ServiceListResponse
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// LogEntry represents an entry in an incident's log.
+type LogEntry struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+	Agent     *Agent    `json:"agent"`
+}
+
+// Agent is the user, service or integration responsible for a log entry.
+type Agent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Summary string `json:"summary"`
+}
+
+// LogEntryListResponse represents the response from listing log entries.
+type LogEntryListResponse struct {
+	LogEntries []LogEntry `json:"log_entries"`
+	Limit      int        `json:"limit"`
+	Offset     int        `json:"offset"`
+	More       bool       `json:"more"`
+}
+
+// ListLogEntries fetches a page of an incident's log entries.
+func (c *Client) ListLogEntries(ctx context.Context, incidentID string, limit, offset int) (*LogEntryListResponse, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if offset > 0 {
+		params.Set("offset", fmt.Sprintf("%d", offset))
+	}
+
+	endpoint := fmt.Sprintf("%s/incidents/%s/log_entries?%s", c.baseURL, incidentID, params.Encode())
+
+	var result LogEntryListResponse
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Alert represents an alert grouped into an incident.
+type Alert struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Summary     string    `json:"summary"`
+	Status      string    `json:"status"`
+	Severity    string    `json:"severity"`
+	AlertKey    string    `json:"alert_key"`
+	CreatedAt   time.Time `json:"created_at"`
+	Integration *Agent    `json:"integration"`
+}
+
+// AlertListResponse represents the response from listing an incident's alerts.
+type AlertListResponse struct {
+	Alerts []Alert `json:"alerts"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+	More   bool    `json:"more"`
+}
+
+// ListAlerts fetches a page of an incident's alerts.
+func (c *Client) ListAlerts(ctx context.Context, incidentID string, limit, offset int) (*AlertListResponse, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if offset > 0 {
+		params.Set("offset", fmt.Sprintf("%d", offset))
+	}
+
+	endpoint := fmt.Sprintf("%s/incidents/%s/alerts?%s", c.baseURL, incidentID, params.Encode())
+
+	var result AlertListResponse
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// User represents a PagerDuty user.
+type User struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Summary string `json:"summary"`
+}
+
+// GetUser fetches a single user by ID.
+func (c *Client) GetUser(ctx context.Context, userID string) (*User, error) {
+	endpoint := fmt.Sprintf("%s/users/%s", c.baseURL, userID)
+
+	var result struct {
+		User User `json:"user"`
+	}
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result.User, nil
+}
+
+// Note represents a note on an incident.
+type Note struct {
+	ID        string    `json:"id"`
+	User      Agent     `json:"user"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListNotes fetches the notes on an incident.
+func (c *Client) ListNotes(ctx context.Context, incidentID string) ([]Note, error) {
+	endpoint := fmt.Sprintf("%s/incidents/%s/notes", c.baseURL, incidentID)
+
+	var result struct {
+		Notes []Note `json:"notes"`
+	}
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Notes, nil
+}
+
+// CreateNote adds a note to an incident. The client must be configured
+// with a From address. Server errors are not retried, since the note may
+// have been created before the error.
+func (c *Client) CreateNote(ctx context.Context, incidentID, content string) (*Note, error) {
+	endpoint := fmt.Sprintf("%s/incidents/%s/notes", c.baseURL, incidentID)
+
+	body := map[string]interface{}{
+		"note": map[string]string{"content": content},
+	}
+
+	var result struct {
+		Note Note `json:"note"`
+	}
+	if err := c.do(ctx, http.MethodPost, endpoint, body, &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Note, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+// Incident relationship types.
+const (
+	RelationshipMachineLearning   = "machine_learning_inferred"
+	RelationshipServiceDependency = "service_dependency"
+	RelationshipManual            = "manual"
+)
+
+// RelatedIncident is an incident PagerDuty considers related to another,
+// with the reasons it does so.
+type RelatedIncident struct {
+	Incident      Incident               `json:"incident"`
+	Relationships []IncidentRelationship `json:"relationships"`
+}
+
+// IncidentRelationship is one reason two incidents are related.
+type IncidentRelationship struct {
+	Type string `json:"type"`
+}
+
+// ListRelatedIncidents fetches the incidents PagerDuty has linked to an
+// incident, whether inferred by machine learning or linked by hand. It
+// returns an empty slice for incidents with none.
+func (c *Client) ListRelatedIncidents(ctx context.Context, incidentID string) ([]RelatedIncident, error) {
+	endpoint := fmt.Sprintf("%s/incidents/%s/related_incidents", c.baseURL, incidentID)
+
+	var result struct {
+		RelatedIncidents []RelatedIncident `json:"related_incidents"`
+	}
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	if result.RelatedIncidents == nil {
+		return []RelatedIncident{}, nil
+	}
+	for i := range result.RelatedIncidents {
+		c.setIncidentSelf(&result.RelatedIncidents[i].Incident)
+	}
+	return result.RelatedIncidents, nil
+}
+
+// Schedule represents a PagerDuty on-call schedule.
+type Schedule struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	Summary       string        `json:"summary"`
+	TimeZone      string        `json:"time_zone"`
+	HTMLURL       string        `json:"html_url"`
+	FinalSchedule ScheduleLayer `json:"final_schedule"`
+}
+
+// ScheduleLayer is a rendered layer of a schedule.
+type ScheduleLayer struct {
+	Name                       string          `json:"name"`
+	RenderedScheduleEntries    []ScheduleEntry `json:"rendered_schedule_entries"`
+	RenderedCoveragePercentage float64         `json:"rendered_coverage_percentage"`
+}
+
+// ScheduleEntry is a period during which a user is on call.
+type ScheduleEntry struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	User  Agent     `json:"user"`
+}
+
+// GetSchedule fetches a schedule with its final layer rendered between
+// since and until.
+func (c *Client) GetSchedule(ctx context.Context, scheduleID string, since, until time.Time) (*Schedule, error) {
+	params := url.Values{}
+	params.Set("since", c.formatTime(since))
+	params.Set("until", c.formatTime(until))
+	if c.timeZone != "" {
+		params.Set("time_zone", c.timeZone)
+	}
+
+	endpoint := fmt.Sprintf("%s/schedules/%s?%s", c.baseURL, scheduleID, params.Encode())
+
+	var result struct {
+		Schedule Schedule `json:"schedule"`
+	}
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Schedule, nil
+}
+
+// do executes a request, retrying rate limited (429) responses, and decodes
+// a successful JSON response into out. Server errors (5xx) are retried too,
+// except for POST requests: PagerDuty may have created the resource before
+// failing, so retrying could create it twice.
+func (c *Client) do(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	return c.doRequest(ctx, method, endpoint, body, out, method != http.MethodPost)
+}
+
+// doRequest is do with explicit control over whether server errors are
+// retried, for POST requests that only read data.
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body, out interface{}, retryServerErrors bool) error {
+	if c.configErr != nil {
+		return c.configErr
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, endpoint, payload)
+		if err != nil {
+			return err
+		}
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests ||
+			(retryServerErrors && isRetryableStatus(resp.StatusCode))
+		if retryable && attempt < maxRetries {
+			wait := retryDelay(resp, attempt)
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusTooManyRequests && c.maxRetryAfter > 0 && wait > c.maxRetryAfter {
+				return fmt.Errorf("%w: retry after %s exceeds cap of %s", ErrRateLimited, wait, c.maxRetryAfter)
+			}
+
+			if c.logger != nil {
+				c.logger.WarnContext(ctx, "retrying pagerduty request",
+					"method", method,
+					"endpoint", endpoint,
+					"attempt", attempt+1,
+					"status", resp.StatusCode,
+					"backoff", wait,
+				)
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		return decodeResponse(resp, out)
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte) (*http.Response, error) {
+	if err := c.waitRateLimit(ctx); err != nil {
+		return nil, fmt.Errorf("wait for rate limiter: %w", err)
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -170,25 +821,71 @@ func (c *Client) GetIncident(ctx context.Context, incidentID string) (*Incident,
 	if err != nil {
 		return nil, fmt.Errorf("execute request: %w", err)
 	}
+
+	return resp, nil
+}
+
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	if c.rateLimiter == nil {
+		return nil
+	}
+	if pl, ok := c.rateLimiter.(priorityLimiter); ok {
+		return pl.WaitPriority(ctx, c.priority)
+	}
+	return c.rateLimiter.Wait(ctx)
+}
+
+func decodeResponse(resp *http.Response, out interface{}) error {
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("pagerduty API error: status=%d body=%s", resp.StatusCode, string(body))
+		apiErr := &APIError{Status: resp.StatusCode, Body: string(body)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return fmt.Errorf("%w: %w", ErrRateLimited, apiErr)
+		}
+		return apiErr
 	}
 
-	var result struct {
-		Incident Incident `json:"incident"`
+	if out == nil {
+		return nil
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
 	}
 
-	return &result.Incident, nil
+	return nil
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryDelay returns how long to wait before retrying. The Retry-After header
+// is honored when present; otherwise the delay backs off exponentially.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+	return baseRetryDelay << attempt
+}
+
+// formatTime formats a query time in the client's time zone.
+func (c *Client) formatTime(t time.Time) string {
+	return t.In(c.location).Format(time.RFC3339)
 }
 
 func (c *Client) setAuth(req *http.Request) {
 	req.Header.Set("Authorization", "Token token="+c.apiKey)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
+	if c.from != "" {
+		req.Header.Set("From", c.from)
+	}
 }
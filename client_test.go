@@ -0,0 +1,210 @@
+package pagerduty
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.Handler, cfg ClientConfig) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg.BaseURL = srv.URL
+	return NewClient(cfg)
+}
+
+// failFirst answers the first n requests with status, then succeeds with
+// body.
+func failFirst(t *testing.T, n int32, status int, body interface{}) (http.Handler, *atomic.Int32) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= n {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			return
+		}
+		writeJSON(t, w, body)
+	})
+	return handler, &calls
+}
+
+func TestClient_CreateNote_DoesNotRetryServerErrors(t *testing.T) {
+	t.Parallel()
+
+	// given
+	handler, calls := failFirst(t, 1, http.StatusBadGateway, map[string]Note{"note": {ID: "N1"}})
+	client := newTestClient(t, handler, ClientConfig{From: "oncall@example.com"})
+
+	// when
+	_, err := client.CreateNote(context.Background(), "P1", "investigating")
+
+	// then
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadGateway {
+		t.Fatalf("got error %v, want a 502 APIError", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestClient_CreateNote_RetriesRateLimit(t *testing.T) {
+	t.Parallel()
+
+	// given
+	handler, calls := failFirst(t, 1, http.StatusTooManyRequests, map[string]Note{"note": {ID: "N1"}})
+	client := newTestClient(t, handler, ClientConfig{From: "oncall@example.com"})
+
+	// when
+	note, err := client.CreateNote(context.Background(), "P1", "investigating")
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if note.ID != "N1" {
+		t.Errorf("got note %q, want N1", note.ID)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestClient_Get_RetriesServerErrors(t *testing.T) {
+	t.Parallel()
+
+	// given
+	handler, calls := failFirst(t, 2, http.StatusBadGateway, map[string]Incident{"incident": {ID: "P1"}})
+	client := newTestClient(t, handler, ClientConfig{})
+
+	// when
+	incident, err := client.GetIncident(context.Background(), "P1")
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if incident.ID != "P1" {
+		t.Errorf("got incident %q, want P1", incident.ID)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestClient_LogsEachRetry(t *testing.T) {
+	t.Parallel()
+
+	// given
+	var logs logRecorder
+	handler, _ := failFirst(t, 1, http.StatusTooManyRequests, map[string]Incident{"incident": {ID: "P1"}})
+	client := newTestClient(t, handler, ClientConfig{Logger: logs.logger()})
+
+	// when
+	_, err := client.GetIncident(context.Background(), "P1")
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records := logs.records(t)
+	if len(records) != 1 {
+		t.Fatalf("got %d log records, want 1", len(records))
+	}
+	if records[0]["attempt"] != float64(1) || records[0]["status"] != float64(http.StatusTooManyRequests) {
+		t.Errorf("got record %v, want attempt 1 with status 429", records[0])
+	}
+	if _, ok := records[0]["backoff"]; !ok {
+		t.Errorf("got record %v, want a backoff", records[0])
+	}
+}
+
+func TestClient_ListIncidents_SendsQueryTimesInUTC(t *testing.T) {
+	t.Parallel()
+
+	// given
+	var query url.Values
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeJSON(t, w, IncidentListResponse{})
+	}), ClientConfig{})
+
+	eastern := time.FixedZone("EST", -5*60*60)
+	since := time.Date(2024, 3, 1, 9, 0, 0, 0, eastern)
+	until := since.Add(time.Hour)
+
+	// when
+	_, err := client.ListIncidentsWithOptions(context.Background(), ListIncidentsOptions{
+		Since: &since,
+		Until: &until,
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := query.Get("since"); got != "2024-03-01T14:00:00Z" {
+		t.Errorf("got since %q, want 2024-03-01T14:00:00Z", got)
+	}
+	if got := query.Get("until"); got != "2024-03-01T15:00:00Z" {
+		t.Errorf("got until %q, want 2024-03-01T15:00:00Z", got)
+	}
+	if query.Has("time_zone") {
+		t.Errorf("got time_zone %q, want none", query.Get("time_zone"))
+	}
+}
+
+func TestClient_UnknownTimeZone_FailsRequests(t *testing.T) {
+	t.Parallel()
+
+	// given
+	handler, calls := failFirst(t, 0, http.StatusOK, IncidentListResponse{})
+	client := newTestClient(t, handler, ClientConfig{TimeZone: "Mars/Olympus_Mons"})
+
+	// when
+	_, err := client.ListIncidentsWithOptions(context.Background(), ListIncidentsOptions{})
+
+	// then
+	if err == nil {
+		t.Fatal("expected an error for an unknown time zone")
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("got %d requests, want none", got)
+	}
+}
+
+func TestClient_MaxRetryAfter_FailsFastOnLongRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	// given
+	var calls atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}), ClientConfig{MaxRetryAfter: time.Second})
+
+	// when
+	start := time.Now()
+	_, err := client.GetIncident(context.Background(), "P1")
+
+	// then
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("got error %v, want ErrRateLimited", err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("took %s, want an immediate failure", elapsed)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
@@ -0,0 +1,99 @@
+package pagerduty
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Content sections for FetchIncidentsInput.ContentSections.
+const (
+	SectionSummary     = "summary"
+	SectionDescription = "description"
+	SectionBody        = "body"
+	SectionNotes       = "notes"
+	SectionAlerts      = "alerts"
+)
+
+func validateContentSections(sections []string) error {
+	for _, section := range sections {
+		switch section {
+		case SectionSummary, SectionDescription, SectionBody, SectionNotes, SectionAlerts:
+		default:
+			return fmt.Errorf("unknown content section %q", section)
+		}
+	}
+	return nil
+}
+
+// assembleContent builds document content from the given sections in
+// order, skipping sections that are empty for this incident. With
+// dedupeAlerts, identical alert summaries are listed once with a count.
+func assembleContent(incident Incident, sections []string, e incidentEnrichment, dedupeAlerts bool) string {
+	var parts []string
+	for _, section := range sections {
+		var part string
+		switch section {
+		case SectionSummary:
+			part = incident.Summary
+		case SectionDescription:
+			part = incident.Description
+		case SectionBody:
+			if incident.Body != nil {
+				part = incident.Body.Details
+			}
+		case SectionNotes:
+			part = notesContent(e.Notes)
+		case SectionAlerts:
+			part = alertsContent(e.Alerts, dedupeAlerts)
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func notesContent(notes []Note) string {
+	if len(notes) == 0 {
+		return ""
+	}
+
+	lines := []string{"Notes:"}
+	for _, note := range notes {
+		lines = append(lines, fmt.Sprintf("- %s %s: %s", note.CreatedAt.Format(time.RFC3339), note.User.Summary, note.Content))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func alertsContent(alerts []Alert, dedupe bool) string {
+	if len(alerts) == 0 {
+		return ""
+	}
+
+	lines := []string{"Alerts:"}
+	if !dedupe {
+		for _, alert := range alerts {
+			lines = append(lines, "- "+alert.Summary)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	var summaries []string
+	counts := make(map[string]int)
+	for _, alert := range alerts {
+		if counts[alert.Summary] == 0 {
+			summaries = append(summaries, alert.Summary)
+		}
+		counts[alert.Summary]++
+	}
+
+	for _, summary := range summaries {
+		line := "- " + summary
+		if n := counts[summary]; n > 1 {
+			line += fmt.Sprintf(" (×%d)", n)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,113 @@
+package pagerduty
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertsContent_DedupeCollapsesDuplicates(t *testing.T) {
+	t.Parallel()
+
+	// given
+	var alerts []Alert
+	for i := 0; i < 37; i++ {
+		alerts = append(alerts, Alert{Summary: "CPU above 90% on web-1"})
+	}
+	alerts = append(alerts, Alert{Summary: "disk full on db-1"})
+
+	// when
+	content := alertsContent(alerts, true)
+
+	// then
+	want := "Alerts:\n- CPU above 90% on web-1 (×37)\n- disk full on db-1"
+	if content != want {
+		t.Errorf("got content %q, want %q", content, want)
+	}
+}
+
+func TestAlertsContent_ListsEveryAlertWithoutDedupe(t *testing.T) {
+	t.Parallel()
+
+	// given
+	alerts := []Alert{{Summary: "disk full"}, {Summary: "disk full"}}
+
+	// when
+	content := alertsContent(alerts, false)
+
+	// then
+	if got := strings.Count(content, "- disk full"); got != 2 {
+		t.Errorf("got %d alert lines, want 2 in %q", got, content)
+	}
+}
+
+func TestFetchIncidentsActivity_RejectsDedupeAlertsWithoutAlertsSection(t *testing.T) {
+	// when
+	_, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{
+		ContentSections: []string{SectionSummary, SectionNotes},
+		DedupeAlerts:    true,
+	})
+
+	// then
+	if err == nil {
+		t.Fatal("expected an error for DedupeAlerts without the alerts section")
+	}
+}
+
+func TestAssembleContent_CustomOrder(t *testing.T) {
+	t.Parallel()
+
+	// given
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	incident := Incident{
+		Summary:     "API latency high",
+		Description: "p99 above 2s",
+		Body:        &IncidentBody{Details: "runbook: https://wiki/api-latency"},
+	}
+	e := incidentEnrichment{
+		Notes:  []Note{{User: Agent{Summary: "Alice"}, Content: "rolling back", CreatedAt: created}},
+		Alerts: []Alert{{Summary: "latency alarm"}},
+	}
+
+	// when
+	content := assembleContent(incident, []string{SectionAlerts, SectionSummary, SectionNotes, SectionBody}, e, false)
+
+	// then
+	want := strings.Join([]string{
+		"Alerts:\n- latency alarm",
+		"API latency high",
+		"Notes:\n- 2024-01-01T12:00:00Z Alice: rolling back",
+		"runbook: https://wiki/api-latency",
+	}, "\n\n")
+	if content != want {
+		t.Errorf("got content %q, want %q", content, want)
+	}
+}
+
+func TestValidateContentSections(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sections []string
+		wantErr  bool
+	}{
+		{name: "known sections", sections: []string{SectionNotes, SectionSummary}},
+		{name: "unknown section", sections: []string{SectionSummary, "timeline"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// when
+			err := validateContentSections(tt.sections)
+
+			// then
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
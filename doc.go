@@ -0,0 +1,16 @@
+// Package pagerduty provides PagerDuty integration activities for resolute workflows.
+//
+// # Backfills
+//
+// BackfillIncidentsActivity fetches a large range of incidents in bounded
+// runs, for backfills too large for a single activity or workflow history.
+// Each run stores up to MaxIncidents documents and returns a checkpoint
+// holding only plain values. The calling workflow processes the run's Ref
+// and, while Done is false, continues as new with the checkpoint:
+//
+//	input.Checkpoint = &out.Checkpoint
+//	return workflow.NewContinueAsNewError(ctx, BackfillWorkflow, input)
+//
+// Each run then starts with a fresh history, so backfills of millions of
+// incidents stay within Temporal's history limits.
+package pagerduty
@@ -0,0 +1,438 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultEnrichConcurrency = 4
+
+	// maxLogEntryPages bounds how much of an incident's log is read when
+	// looking for a particular entry.
+	maxLogEntryPages = 10
+
+	// maxAlertPages bounds how many of an incident's alerts are read.
+	maxAlertPages = 5
+)
+
+// incidentEnrichment is extra data looked up for an incident after listing.
+// Notes, alerts and log entries are loaded on first use and shared between
+// enrichers, so each is fetched at most once per incident.
+type incidentEnrichment struct {
+	Metadata map[string]string
+
+	// Exclude drops the incident from the results.
+	Exclude bool
+
+	Notes      []Note
+	Alerts     []Alert
+	LogEntries []LogEntry
+
+	// Related holds the incident's related incidents when they were looked
+	// up.
+	Related []RelatedIncident
+
+	notesLoaded      bool
+	alertsLoaded     bool
+	logEntriesLoaded bool
+}
+
+func (e *incidentEnrichment) notes(ctx context.Context, client *Client, incidentID string) ([]Note, error) {
+	if !e.notesLoaded {
+		notes, err := client.ListNotes(ctx, incidentID)
+		if err != nil {
+			return nil, fmt.Errorf("list notes: %w", err)
+		}
+		e.Notes, e.notesLoaded = notes, true
+	}
+	return e.Notes, nil
+}
+
+func (e *incidentEnrichment) alerts(ctx context.Context, client *Client, incidentID string) ([]Alert, error) {
+	if !e.alertsLoaded {
+		alerts, err := listAlerts(ctx, client, incidentID)
+		if err != nil {
+			return nil, err
+		}
+		e.Alerts, e.alertsLoaded = alerts, true
+	}
+	return e.Alerts, nil
+}
+
+func (e *incidentEnrichment) logEntries(ctx context.Context, client *Client, incidentID string) ([]LogEntry, error) {
+	if !e.logEntriesLoaded {
+		entries, err := listLogEntries(ctx, client, incidentID)
+		if err != nil {
+			return nil, err
+		}
+		e.LogEntries, e.logEntriesLoaded = entries, true
+	}
+	return e.LogEntries, nil
+}
+
+// enrichFunc looks up extra data for a single incident and records it in e.
+type enrichFunc func(ctx context.Context, incident Incident, e *incidentEnrichment) error
+
+// enrichIncidents runs the enrichers for each incident with at most
+// concurrency incidents in flight. Results are returned in incident order.
+// Enrichers run in order and stop at the first one that excludes the
+// incident.
+func enrichIncidents(ctx context.Context, incidents []Incident, concurrency int, enrichers []enrichFunc) ([]incidentEnrichment, error) {
+	results := make([]incidentEnrichment, len(incidents))
+	for i := range results {
+		results[i].Metadata = make(map[string]string)
+	}
+	if len(enrichers) == 0 {
+		return results, nil
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	runBounded(len(incidents), concurrency, func(i int) {
+		incident := incidents[i]
+		for _, enrich := range enrichers {
+			if err := enrich(ctx, incident, &results[i]); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("enrich incident %s: %w", incident.ID, err)
+				}
+				mu.Unlock()
+				return
+			}
+			if results[i].Exclude {
+				return
+			}
+		}
+	})
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return results, nil
+}
+
+// listLogEntries reads up to maxLogEntryPages pages of an incident's log.
+func listLogEntries(ctx context.Context, client *Client, incidentID string) ([]LogEntry, error) {
+	var entries []LogEntry
+	offset := 0
+	for page := 0; page < maxLogEntryPages; page++ {
+		result, err := client.ListLogEntries(ctx, incidentID, 100, offset)
+		if err != nil {
+			return nil, fmt.Errorf("list log entries: %w", err)
+		}
+		entries = append(entries, result.LogEntries...)
+		if !result.More || len(result.LogEntries) == 0 {
+			break
+		}
+		offset += len(result.LogEntries)
+	}
+	return entries, nil
+}
+
+// listAlerts reads up to maxAlertPages pages of an incident's alerts.
+func listAlerts(ctx context.Context, client *Client, incidentID string) ([]Alert, error) {
+	var alerts []Alert
+	offset := 0
+	for page := 0; page < maxAlertPages; page++ {
+		result, err := client.ListAlerts(ctx, incidentID, 100, offset)
+		if err != nil {
+			return nil, fmt.Errorf("list alerts: %w", err)
+		}
+		alerts = append(alerts, result.Alerts...)
+		if !result.More || len(result.Alerts) == 0 {
+			break
+		}
+		offset += len(result.Alerts)
+	}
+	return alerts, nil
+}
+
+// integrationFilter excludes incidents with no alert from one of the given
+// integrations.
+func integrationFilter(client *Client, integrationIDs []string) enrichFunc {
+	allowed := make(map[string]bool, len(integrationIDs))
+	for _, id := range integrationIDs {
+		allowed[id] = true
+	}
+
+	return func(ctx context.Context, incident Incident, e *incidentEnrichment) error {
+		alerts, err := e.alerts(ctx, client, incident.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, alert := range alerts {
+			if alert.Integration != nil && allowed[alert.Integration.ID] {
+				return nil
+			}
+		}
+
+		e.Exclude = true
+		return nil
+	}
+}
+
+// escalationLevelPattern finds the level an escalate log entry's summary
+// reports, e.g. "Escalated to level 2 by ...".
+var escalationLevelPattern = regexp.MustCompile(`(?i)\blevel (\d+)`)
+
+// escalationFilter excludes incidents that never left the first level of
+// their escalation policy and records max_escalation_level for the rest.
+// The level is taken from each escalate log entry's summary; when a summary
+// does not state it, each escalation counts as one level up.
+func escalationFilter(client *Client) enrichFunc {
+	return func(ctx context.Context, incident Incident, e *incidentEnrichment) error {
+		entries, err := e.logEntries(ctx, client, incident.ID)
+		if err != nil {
+			return err
+		}
+
+		maxLevel, escalations := 1, 0
+		for _, entry := range entries {
+			if entry.Type != "escalate_log_entry" {
+				continue
+			}
+			escalations++
+
+			level := escalations + 1
+			if m := escalationLevelPattern.FindStringSubmatch(entry.Summary); m != nil {
+				level, _ = strconv.Atoi(m[1])
+			}
+			if level > maxLevel {
+				maxLevel = level
+			}
+		}
+
+		if maxLevel <= 1 {
+			e.Exclude = true
+			return nil
+		}
+
+		e.Metadata["max_escalation_level"] = strconv.Itoa(maxLevel)
+		return nil
+	}
+}
+
+// serviceEnricher looks up each incident's service to record its current
+// service_status. Services that no longer exist are recorded as
+// service_deleted rather than failing the incident. Each service is looked
+// up at most once per run.
+func serviceEnricher(client *Client) enrichFunc {
+	type lookup struct {
+		once    sync.Once
+		service *Service
+		err     error
+	}
+
+	var mu sync.Mutex
+	lookups := make(map[string]*lookup)
+
+	return func(ctx context.Context, incident Incident, e *incidentEnrichment) error {
+		if incident.Service.ID == "" {
+			return nil
+		}
+
+		mu.Lock()
+		l, ok := lookups[incident.Service.ID]
+		if !ok {
+			l = &lookup{}
+			lookups[incident.Service.ID] = l
+		}
+		mu.Unlock()
+
+		l.once.Do(func() {
+			l.service, l.err = client.GetService(ctx, incident.Service.ID)
+		})
+
+		if IsNotFound(l.err) {
+			e.Metadata["service_deleted"] = "true"
+			return nil
+		}
+		if l.err != nil {
+			return fmt.Errorf("get service %s: %w", incident.Service.ID, l.err)
+		}
+
+		if l.service.Status != "" {
+			e.Metadata["service_status"] = l.service.Status
+		}
+		return nil
+	}
+}
+
+// resolutionOptions selects what resolutionEnricher records.
+type resolutionOptions struct {
+	ResolvedBy      bool
+	ResolvedByEmail bool
+	AutoResolved    bool
+}
+
+// resolutionEnricher inspects a resolved incident's resolve log entry.
+//
+// With ResolvedBy it records who resolved the incident: human resolvers get
+// resolved_by_type "user", while automated resolutions record the agent
+// kind, e.g. "service" or "integration".
+//
+// With AutoResolved it records auto_resolved, which is "true" only when the
+// incident itself was closed by something other than a user. An incident
+// whose alerts partly auto-resolved but which a responder then closed by
+// hand is reported as "false".
+func resolutionEnricher(client *Client, opts resolutionOptions) enrichFunc {
+	return func(ctx context.Context, incident Incident, e *incidentEnrichment) error {
+		if incident.Status != StatusResolved {
+			return nil
+		}
+
+		entries, err := e.logEntries(ctx, client, incident.ID)
+		if err != nil {
+			return err
+		}
+
+		var resolve *LogEntry
+		for i := range entries {
+			if entries[i].Type != "resolve_log_entry" {
+				continue
+			}
+			if resolve == nil || entries[i].CreatedAt.After(resolve.CreatedAt) {
+				resolve = &entries[i]
+			}
+		}
+		if resolve == nil {
+			return nil
+		}
+
+		byUser := resolve.Agent != nil && resolve.Agent.Type == "user_reference"
+
+		if opts.AutoResolved {
+			e.Metadata["auto_resolved"] = strconv.FormatBool(!byUser)
+		}
+
+		if !opts.ResolvedBy && !opts.ResolvedByEmail {
+			return nil
+		}
+
+		if resolve.Agent == nil {
+			e.Metadata["resolved_by_type"] = "system"
+			return nil
+		}
+
+		e.Metadata["resolved_by"] = resolve.Agent.Summary
+		e.Metadata["resolved_by_type"] = agentKind(resolve.Agent.Type)
+
+		if opts.ResolvedByEmail && byUser {
+			user, err := client.GetUser(ctx, resolve.Agent.ID)
+			if err != nil {
+				return fmt.Errorf("get user %s: %w", resolve.Agent.ID, err)
+			}
+			e.Metadata["resolved_by"] = user.Name
+			if user.Email != "" {
+				e.Metadata["resolved_by_email"] = user.Email
+			}
+		}
+
+		return nil
+	}
+}
+
+// timeToFirstNoteEnricher records seconds_to_first_note, the time from an
+// incident's creation to the first note left by a user. Incidents without
+// user notes are left without the field.
+func timeToFirstNoteEnricher(client *Client) enrichFunc {
+	return func(ctx context.Context, incident Incident, e *incidentEnrichment) error {
+		notes, err := e.notes(ctx, client, incident.ID)
+		if err != nil {
+			return err
+		}
+
+		var first *Note
+		for i := range notes {
+			if notes[i].User.Type != "user_reference" {
+				continue
+			}
+			if first == nil || notes[i].CreatedAt.Before(first.CreatedAt) {
+				first = &notes[i]
+			}
+		}
+		if first == nil {
+			return nil
+		}
+
+		seconds := int64(first.CreatedAt.Sub(incident.CreatedAt).Seconds())
+		if seconds < 0 {
+			seconds = 0
+		}
+
+		e.Metadata["seconds_to_first_note"] = strconv.FormatInt(seconds, 10)
+		return nil
+	}
+}
+
+// relatedEnricher looks up each incident's related incidents and records
+// their IDs as related_incidents and their relationship types as
+// related_incident_types, both comma-separated and in the same order.
+// Incidents with no related incidents are left without either field.
+func relatedEnricher(client *Client) enrichFunc {
+	return func(ctx context.Context, incident Incident, e *incidentEnrichment) error {
+		related, err := client.ListRelatedIncidents(ctx, incident.ID)
+		if err != nil {
+			return fmt.Errorf("list related incidents: %w", err)
+		}
+		e.Related = related
+		if len(related) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(related))
+		types := make([]string, len(related))
+		for i, r := range related {
+			ids[i] = r.Incident.ID
+			types[i] = relationshipType(r)
+		}
+
+		e.Metadata["related_incidents"] = strings.Join(ids, ",")
+		e.Metadata["related_incident_types"] = strings.Join(types, ",")
+		return nil
+	}
+}
+
+// relationshipType returns the first relationship type of a related
+// incident, or "unknown" when PagerDuty gave none.
+func relationshipType(r RelatedIncident) string {
+	if len(r.Relationships) == 0 || r.Relationships[0].Type == "" {
+		return "unknown"
+	}
+	return r.Relationships[0].Type
+}
+
+// contentEnricher loads the notes and alerts needed to assemble the
+// requested content sections.
+func contentEnricher(client *Client, sections []string) enrichFunc {
+	return func(ctx context.Context, incident Incident, e *incidentEnrichment) error {
+		for _, section := range sections {
+			var err error
+			switch section {
+			case SectionNotes:
+				_, err = e.notes(ctx, client, incident.ID)
+			case SectionAlerts:
+				_, err = e.alerts(ctx, client, incident.ID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// agentKind maps a reference type such as "user_reference" to "user".
+func agentKind(refType string) string {
+	return strings.TrimSuffix(refType, "_reference")
+}
@@ -0,0 +1,274 @@
+package pagerduty
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// apiRoutes serves each path's JSON body and answers anything else with 404.
+func apiRoutes(t *testing.T, routes map[string]interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(t, w, map[string]interface{}{"error": map[string]string{"message": "Not Found"}})
+			return
+		}
+		writeJSON(t, w, body)
+	})
+}
+
+// enrich runs a single enricher for incident and returns what it recorded.
+func enrich(t *testing.T, fn enrichFunc, incident Incident) incidentEnrichment {
+	t.Helper()
+
+	e := incidentEnrichment{Metadata: make(map[string]string)}
+	if err := fn(context.Background(), incident, &e); err != nil {
+		t.Fatalf("enrich incident %s: unexpected error: %v", incident.ID, err)
+	}
+	return e
+}
+
+func TestResolutionEnricher_HumanAndAutomatedResolvers(t *testing.T) {
+	t.Parallel()
+
+	// given
+	client := newTestClient(t, apiRoutes(t, map[string]interface{}{
+		"/incidents/P1/log_entries": LogEntryListResponse{LogEntries: []LogEntry{
+			{Type: "trigger_log_entry", Agent: &Agent{ID: "PS1", Type: "service_reference", Summary: "API"}},
+			{Type: "resolve_log_entry", Agent: &Agent{ID: "U1", Type: "user_reference", Summary: "Alice"}},
+		}},
+		"/incidents/P2/log_entries": LogEntryListResponse{LogEntries: []LogEntry{
+			{Type: "resolve_log_entry", Agent: &Agent{ID: "PS1", Type: "service_reference", Summary: "API"}},
+		}},
+		"/users/U1": map[string]User{"user": {ID: "U1", Name: "Alice Smith", Email: "alice@example.com"}},
+	}), ClientConfig{})
+	enricher := resolutionEnricher(client, resolutionOptions{ResolvedBy: true, ResolvedByEmail: true, AutoResolved: true})
+
+	tests := []struct {
+		name     string
+		incident Incident
+		want     map[string]string
+	}{
+		{
+			name:     "resolved by a user",
+			incident: Incident{ID: "P1", Status: StatusResolved},
+			want: map[string]string{
+				"resolved_by":       "Alice Smith",
+				"resolved_by_email": "alice@example.com",
+				"resolved_by_type":  "user",
+				"auto_resolved":     "false",
+			},
+		},
+		{
+			name:     "resolved by a service",
+			incident: Incident{ID: "P2", Status: StatusResolved},
+			want: map[string]string{
+				"resolved_by":      "API",
+				"resolved_by_type": "service",
+				"auto_resolved":    "true",
+			},
+		},
+		{
+			name:     "not resolved",
+			incident: Incident{ID: "P3", Status: StatusTriggered},
+			want:     map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// when
+			e := enrich(t, enricher, tt.incident)
+
+			// then
+			if len(e.Metadata) != len(tt.want) {
+				t.Errorf("got metadata %v, want %v", e.Metadata, tt.want)
+			}
+			for key, want := range tt.want {
+				if got := e.Metadata[key]; got != want {
+					t.Errorf("got %s %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeToFirstNoteEnricher(t *testing.T) {
+	t.Parallel()
+
+	// given
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	client := newTestClient(t, apiRoutes(t, map[string]interface{}{
+		"/incidents/P1/notes": map[string][]Note{"notes": {
+			{User: Agent{Type: "user_reference"}, CreatedAt: created.Add(10 * time.Minute)},
+			{User: Agent{Type: "service_reference"}, CreatedAt: created.Add(time.Minute)},
+			{User: Agent{Type: "user_reference"}, CreatedAt: created.Add(5 * time.Minute)},
+		}},
+		"/incidents/P2/notes": map[string][]Note{"notes": {}},
+	}), ClientConfig{})
+	enricher := timeToFirstNoteEnricher(client)
+
+	tests := []struct {
+		name     string
+		incident Incident
+		want     string
+	}{
+		{
+			name:     "first user note",
+			incident: Incident{ID: "P1", CreatedAt: created},
+			want:     "300",
+		},
+		{
+			name:     "no notes",
+			incident: Incident{ID: "P2", CreatedAt: created},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// when
+			e := enrich(t, enricher, tt.incident)
+
+			// then
+			got, ok := e.Metadata["seconds_to_first_note"]
+			if tt.want == "" {
+				if ok {
+					t.Errorf("got seconds_to_first_note %q, want none", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got seconds_to_first_note %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntegrationFilter_MixedIntegrations(t *testing.T) {
+	t.Parallel()
+
+	// given
+	datadog := &Agent{ID: "PDATADOG", Type: "inbound_integration_reference"}
+	cloudwatch := &Agent{ID: "PCLOUDWATCH", Type: "inbound_integration_reference"}
+	client := newTestClient(t, apiRoutes(t, map[string]interface{}{
+		"/incidents/P1/alerts": AlertListResponse{Alerts: []Alert{{Integration: cloudwatch}, {Integration: datadog}}},
+		"/incidents/P2/alerts": AlertListResponse{Alerts: []Alert{{Integration: cloudwatch}}},
+		"/incidents/P3/alerts": AlertListResponse{Alerts: []Alert{{Summary: "no integration"}}},
+	}), ClientConfig{})
+	filter := integrationFilter(client, []string{"PDATADOG"})
+
+	tests := []struct {
+		incidentID string
+		wantKept   bool
+	}{
+		{incidentID: "P1", wantKept: true},
+		{incidentID: "P2", wantKept: false},
+		{incidentID: "P3", wantKept: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.incidentID, func(t *testing.T) {
+			t.Parallel()
+
+			// when
+			e := enrich(t, filter, Incident{ID: tt.incidentID})
+
+			// then
+			if kept := !e.Exclude; kept != tt.wantKept {
+				t.Errorf("got kept %t, want %t", kept, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestFetchIncidentsActivity_KeepsIncidentsOfDeletedServices(t *testing.T) {
+	// given
+	useTestAPI(t, apiRoutes(t, map[string]interface{}{
+		"/incidents": IncidentListResponse{Incidents: []Incident{
+			{ID: "P1", Service: Service{ID: "PDELETED"}},
+			{ID: "P2", Service: Service{ID: "PLIVE"}},
+		}},
+		"/services/PLIVE": map[string]Service{"service": {ID: "PLIVE", Status: "active"}},
+	}))
+
+	// when
+	_, docs := fetchIncidentDocs(t, FetchIncidentsInput{LookupServices: true})
+
+	// then
+	byID := docsByID(docs)
+	if len(byID) != 2 {
+		t.Fatalf("got %d documents, want 2", len(byID))
+	}
+	if got := byID["P1"].Metadata["service_deleted"]; got != "true" {
+		t.Errorf("got service_deleted %q for P1, want true", got)
+	}
+	if got := byID["P2"].Metadata["service_status"]; got != "active" {
+		t.Errorf("got service_status %q for P2, want active", got)
+	}
+	if _, ok := byID["P2"].Metadata["service_deleted"]; ok {
+		t.Error("got service_deleted for P2, want none")
+	}
+}
+
+func TestEscalationFilter(t *testing.T) {
+	t.Parallel()
+
+	// given
+	client := newTestClient(t, apiRoutes(t, map[string]interface{}{
+		"/incidents/P1/log_entries": LogEntryListResponse{LogEntries: []LogEntry{
+			{Type: "trigger_log_entry"},
+			{Type: "escalate_log_entry", Summary: "Escalated to level 2 by timeout"},
+			{Type: "escalate_log_entry", Summary: "Escalated to level 3 by timeout"},
+		}},
+		"/incidents/P2/log_entries": LogEntryListResponse{LogEntries: []LogEntry{
+			{Type: "trigger_log_entry"},
+			{Type: "escalate_log_entry", Summary: "Escalated by Alice"},
+		}},
+		"/incidents/P3/log_entries": LogEntryListResponse{LogEntries: []LogEntry{
+			{Type: "trigger_log_entry"},
+			{Type: "acknowledge_log_entry"},
+			{Type: "resolve_log_entry"},
+		}},
+	}), ClientConfig{})
+	filter := escalationFilter(client)
+
+	tests := []struct {
+		name       string
+		incidentID string
+		wantLevel  string
+	}{
+		{name: "escalated with stated levels", incidentID: "P1", wantLevel: "3"},
+		{name: "escalated without a stated level", incidentID: "P2", wantLevel: "2"},
+		{name: "resolved at level 1", incidentID: "P3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// when
+			e := enrich(t, filter, Incident{ID: tt.incidentID, Status: StatusResolved})
+
+			// then
+			if tt.wantLevel == "" {
+				if !e.Exclude {
+					t.Errorf("got incident kept with metadata %v, want it excluded", e.Metadata)
+				}
+				return
+			}
+			if e.Exclude {
+				t.Fatal("got incident excluded, want it kept")
+			}
+			if got := e.Metadata["max_escalation_level"]; got != tt.wantLevel {
+				t.Errorf("got max_escalation_level %q, want %q", got, tt.wantLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,418 @@
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const eventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// ErrInvalidRoutingKey is returned by ValidateRoutingKey when the Events API
+// rejects the routing key.
+var ErrInvalidRoutingKey = errors.New("pagerduty: invalid routing key")
+
+// ErrDedupKeyNotFound is returned by a DedupKeyStore that holds no dedup key
+// for an alert key.
+var ErrDedupKeyNotFound = errors.New("pagerduty: dedup key not found")
+
+// Event actions.
+const (
+	EventActionTrigger     = "trigger"
+	EventActionAcknowledge = "acknowledge"
+	EventActionResolve     = "resolve"
+)
+
+// EventsClient is a PagerDuty Events API v2 client.
+type EventsClient struct {
+	url        string
+	httpClient *http.Client
+	dedupKeys  DedupKeyStore
+	logger     *slog.Logger
+}
+
+// EventsClientConfig contains configuration for creating an Events API client.
+type EventsClientConfig struct {
+	Timeout time.Duration
+
+	// URL overrides the Events API endpoint, e.g. for PagerDuty's EU
+	// service region. Defaults to https://events.pagerduty.com/v2/enqueue.
+	URL string
+
+	// DedupKeyStore, if set, lets TriggerAlert, AcknowledgeAlert and
+	// ResolveAlert correlate events by a caller-chosen alert key. Trigger,
+	// Acknowledge and Resolve take dedup keys directly and never consult
+	// it, so callers that track dedup keys themselves can keep doing so.
+	DedupKeyStore DedupKeyStore
+
+	// Logger, if set, receives a warning each time an event is retried
+	// after a network failure, 429 or 5xx response.
+	Logger *slog.Logger
+}
+
+// DedupKeyStore maps logical alert keys to the dedup keys of the alerts
+// triggered for them, so a workflow can resolve an alert without threading
+// its dedup key through workflow state. Get returns ErrDedupKeyNotFound for
+// unknown alert keys. Stores that also have a Delete(alertKey string) error
+// method forget an alert key once its alert is resolved. Implementations
+// must be safe for concurrent use.
+type DedupKeyStore interface {
+	Put(alertKey, dedupKey string) error
+	Get(alertKey string) (string, error)
+}
+
+// dedupKeyDeleter is implemented by dedup key stores that can forget an
+// alert key.
+type dedupKeyDeleter interface {
+	Delete(alertKey string) error
+}
+
+// NewEventsClient creates a new Events API client.
+func NewEventsClient(cfg EventsClientConfig) *EventsClient {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	endpoint := cfg.URL
+	if endpoint == "" {
+		endpoint = eventsURL
+	}
+
+	return &EventsClient{
+		url: endpoint,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		dedupKeys: cfg.DedupKeyStore,
+		logger:    cfg.Logger,
+	}
+}
+
+// Event is an Events API v2 event.
+type Event struct {
+	RoutingKey string        `json:"routing_key"`
+	Action     string        `json:"event_action"`
+	DedupKey   string        `json:"dedup_key,omitempty"`
+	Payload    *EventPayload `json:"payload,omitempty"`
+}
+
+// EventPayload describes the alert raised by a trigger event.
+type EventPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     *time.Time             `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// EventResponse is the Events API response to an enqueued event.
+type EventResponse struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	DedupKey string `json:"dedup_key"`
+}
+
+// Send enqueues an event, retrying network failures, rate limiting and
+// server errors, honoring Retry-After when PagerDuty sends it. Trigger
+// events without a dedup key are assigned one before the first attempt, and
+// the same key is sent on every retry so PagerDuty deduplicates rather than
+// opening a second incident.
+//
+// The returned response always carries the dedup key, even when Send fails,
+// so the caller can retry later without risking a duplicate.
+func (c *EventsClient) Send(ctx context.Context, event Event) (EventResponse, error) {
+	if event.DedupKey == "" {
+		if event.Action != EventActionTrigger {
+			return EventResponse{}, fmt.Errorf("dedup key is required for %s events", event.Action)
+		}
+		key, err := newDedupKey()
+		if err != nil {
+			return EventResponse{}, fmt.Errorf("generate dedup key: %w", err)
+		}
+		event.DedupKey = key
+	}
+
+	failed := EventResponse{DedupKey: event.DedupKey}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return failed, fmt.Errorf("encode event: %w", err)
+	}
+
+	var (
+		lastErr error
+		wait    time.Duration
+	)
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return failed, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		resp, err := c.post(ctx, payload)
+		if err != nil {
+			lastErr = err
+			wait = baseRetryDelay << attempt
+			c.logRetry(ctx, attempt, wait, "error", err)
+			continue
+		}
+
+		if isRetryableStatus(resp.StatusCode) {
+			wait = retryDelay(resp, attempt)
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			lastErr = &APIError{Status: resp.StatusCode, Body: string(body)}
+			c.logRetry(ctx, attempt, wait, "status", resp.StatusCode)
+			continue
+		}
+
+		result, err := decodeEventResponse(resp)
+		if err != nil {
+			return failed, err
+		}
+		if result.DedupKey == "" {
+			result.DedupKey = event.DedupKey
+		}
+		return result, nil
+	}
+
+	return failed, fmt.Errorf("send event after %d attempts: %w", maxRetries+1, lastErr)
+}
+
+// logRetry reports that a failed attempt will be retried after wait, with
+// the status or error it failed with. The last attempt is not retried, so
+// it is not reported.
+func (c *EventsClient) logRetry(ctx context.Context, attempt int, wait time.Duration, key string, value interface{}) {
+	if c.logger == nil || attempt == maxRetries {
+		return
+	}
+	c.logger.WarnContext(ctx, "retrying pagerduty event",
+		"endpoint", c.url,
+		"attempt", attempt+1,
+		key, value,
+		"backoff", wait,
+	)
+}
+
+// Trigger raises an alert. Pass an empty dedupKey to have one generated.
+func (c *EventsClient) Trigger(ctx context.Context, routingKey, dedupKey string, payload EventPayload) (EventResponse, error) {
+	return c.Send(ctx, Event{
+		RoutingKey: routingKey,
+		Action:     EventActionTrigger,
+		DedupKey:   dedupKey,
+		Payload:    &payload,
+	})
+}
+
+// Acknowledge acknowledges the alert with the given dedup key.
+func (c *EventsClient) Acknowledge(ctx context.Context, routingKey, dedupKey string) (EventResponse, error) {
+	return c.Send(ctx, Event{
+		RoutingKey: routingKey,
+		Action:     EventActionAcknowledge,
+		DedupKey:   dedupKey,
+	})
+}
+
+// Resolve resolves the alert with the given dedup key.
+func (c *EventsClient) Resolve(ctx context.Context, routingKey, dedupKey string) (EventResponse, error) {
+	return c.Send(ctx, Event{
+		RoutingKey: routingKey,
+		Action:     EventActionResolve,
+		DedupKey:   dedupKey,
+	})
+}
+
+// TriggerAlert raises an alert for a logical alert key. The dedup key is
+// looked up in the client's DedupKeyStore, so repeated triggers for the
+// same alert key update one alert; for a new alert key one is generated and
+// stored. It is stored even when the trigger fails, so a retry reuses it.
+func (c *EventsClient) TriggerAlert(ctx context.Context, routingKey, alertKey string, payload EventPayload) (EventResponse, error) {
+	if c.dedupKeys == nil {
+		return EventResponse{}, errors.New("no DedupKeyStore configured")
+	}
+
+	dedupKey, err := c.dedupKeys.Get(alertKey)
+	if err != nil && !errors.Is(err, ErrDedupKeyNotFound) {
+		return EventResponse{}, fmt.Errorf("get dedup key for %s: %w", alertKey, err)
+	}
+
+	resp, sendErr := c.Trigger(ctx, routingKey, dedupKey, payload)
+	if dedupKey == "" && resp.DedupKey != "" {
+		if err := c.dedupKeys.Put(alertKey, resp.DedupKey); err != nil {
+			return resp, fmt.Errorf("store dedup key for %s: %w", alertKey, err)
+		}
+	}
+	return resp, sendErr
+}
+
+// AcknowledgeAlert acknowledges the alert triggered for an alert key.
+func (c *EventsClient) AcknowledgeAlert(ctx context.Context, routingKey, alertKey string) (EventResponse, error) {
+	dedupKey, err := c.storedDedupKey(alertKey)
+	if err != nil {
+		return EventResponse{}, err
+	}
+	return c.Acknowledge(ctx, routingKey, dedupKey)
+}
+
+// ResolveAlert resolves the alert triggered for an alert key. Once the
+// resolve succeeds the alert key is removed from stores that support
+// deletion, so a later TriggerAlert for it raises a new alert.
+func (c *EventsClient) ResolveAlert(ctx context.Context, routingKey, alertKey string) (EventResponse, error) {
+	dedupKey, err := c.storedDedupKey(alertKey)
+	if err != nil {
+		return EventResponse{}, err
+	}
+
+	resp, err := c.Resolve(ctx, routingKey, dedupKey)
+	if err != nil {
+		return resp, err
+	}
+	if d, ok := c.dedupKeys.(dedupKeyDeleter); ok {
+		if err := d.Delete(alertKey); err != nil {
+			return resp, fmt.Errorf("delete dedup key for %s: %w", alertKey, err)
+		}
+	}
+	return resp, nil
+}
+
+func (c *EventsClient) storedDedupKey(alertKey string) (string, error) {
+	if c.dedupKeys == nil {
+		return "", errors.New("no DedupKeyStore configured")
+	}
+	dedupKey, err := c.dedupKeys.Get(alertKey)
+	if err != nil {
+		return "", fmt.Errorf("get dedup key for %s: %w", alertKey, err)
+	}
+	return dedupKey, nil
+}
+
+// MemoryDedupKeyStore is a DedupKeyStore held in memory. Its keys do not
+// survive a worker restart, so it suits short-lived alerts and tests;
+// durable correlation needs a store backed by a database or cache.
+type MemoryDedupKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]string
+}
+
+var (
+	_ DedupKeyStore   = (*MemoryDedupKeyStore)(nil)
+	_ dedupKeyDeleter = (*MemoryDedupKeyStore)(nil)
+)
+
+// NewMemoryDedupKeyStore creates an empty in-memory dedup key store.
+func NewMemoryDedupKeyStore() *MemoryDedupKeyStore {
+	return &MemoryDedupKeyStore{keys: make(map[string]string)}
+}
+
+// Put records the dedup key for an alert key.
+func (s *MemoryDedupKeyStore) Put(alertKey, dedupKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[alertKey] = dedupKey
+	return nil
+}
+
+// Get returns the dedup key for an alert key.
+func (s *MemoryDedupKeyStore) Get(alertKey string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	dedupKey, ok := s.keys[alertKey]
+	if !ok {
+		return "", ErrDedupKeyNotFound
+	}
+	return dedupKey, nil
+}
+
+// Delete forgets the dedup key for an alert key.
+func (s *MemoryDedupKeyStore) Delete(alertKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, alertKey)
+	return nil
+}
+
+// ValidateRoutingKey checks that the Events API accepts routingKey by sending
+// a probe trigger event and immediately resolving it.
+//
+// This has side effects: the probe opens a real alert, and usually an
+// incident, on the service behind the key. It is sent with "info" severity
+// so urgency rules that map low severities to low urgency avoid paging, but
+// responders may still be notified depending on the service's settings.
+// If the resolve fails the probe is left open and the returned error carries
+// its dedup key.
+func (c *EventsClient) ValidateRoutingKey(ctx context.Context, routingKey string) error {
+	resp, err := c.Trigger(ctx, routingKey, "", EventPayload{
+		Summary:  "Routing key validation probe from resolute-pagerduty; resolves automatically",
+		Source:   ProviderName,
+		Severity: "info",
+	})
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.Status == http.StatusBadRequest || apiErr.Status == http.StatusNotFound) {
+			return fmt.Errorf("%w: %w", ErrInvalidRoutingKey, err)
+		}
+		return fmt.Errorf("send probe: %w", err)
+	}
+
+	if _, err := c.Resolve(ctx, routingKey, resp.DedupKey); err != nil {
+		return fmt.Errorf("resolve probe with dedup key %s: %w", resp.DedupKey, err)
+	}
+
+	return nil
+}
+
+func (c *EventsClient) post(ctx context.Context, payload []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("execute request: %w", err)
+	}
+
+	return resp, nil
+}
+
+func decodeEventResponse(resp *http.Response) (EventResponse, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return EventResponse{}, &APIError{Status: resp.StatusCode, Body: string(body)}
+	}
+
+	var result EventResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return EventResponse{}, fmt.Errorf("decode response: %w", err)
+	}
+
+	return result, nil
+}
+
+func newDedupKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,296 @@
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// eventRecorder is an Events API stub that records the events it receives
+// and answers each with the next status in statuses, then 202.
+type eventRecorder struct {
+	t        *testing.T
+	mu       sync.Mutex
+	events   []Event
+	statuses []int
+}
+
+func (r *eventRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var event Event
+	if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
+		r.t.Errorf("decode event: %v", err)
+	}
+
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	status := http.StatusAccepted
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	r.mu.Unlock()
+
+	if status != http.StatusAccepted {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(status)
+		return
+	}
+
+	w.WriteHeader(status)
+	writeJSON(r.t, w, EventResponse{Status: "success", Message: "Event processed", DedupKey: event.DedupKey})
+}
+
+func (r *eventRecorder) received() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+func newTestEventsClient(t *testing.T, recorder *eventRecorder, cfg EventsClientConfig) *EventsClient {
+	t.Helper()
+
+	recorder.t = t
+	srv := httptest.NewServer(recorder)
+	t.Cleanup(srv.Close)
+
+	cfg.URL = srv.URL
+	return NewEventsClient(cfg)
+}
+
+func TestEventsClient_Send_ReusesDedupKeyAcrossRetries(t *testing.T) {
+	t.Parallel()
+
+	// given
+	recorder := &eventRecorder{statuses: []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusInternalServerError,
+	}}
+	client := newTestEventsClient(t, recorder, EventsClientConfig{})
+
+	// when
+	resp, err := client.Trigger(context.Background(), "routing-key", "", EventPayload{
+		Summary:  "disk full",
+		Source:   "db-1",
+		Severity: "critical",
+	})
+
+	// then
+	if err == nil {
+		t.Fatal("expected an error after exhausting retries")
+	}
+	if resp.DedupKey == "" {
+		t.Fatal("expected the dedup key to be returned on failure")
+	}
+
+	events := recorder.received()
+	if len(events) != maxRetries+1 {
+		t.Fatalf("got %d attempts, want %d", len(events), maxRetries+1)
+	}
+	for i, event := range events {
+		if event.DedupKey != resp.DedupKey {
+			t.Errorf("attempt %d sent dedup key %q, want %q", i, event.DedupKey, resp.DedupKey)
+		}
+	}
+}
+
+func TestEventsClient_Send_SucceedsAfterRetry(t *testing.T) {
+	t.Parallel()
+
+	// given
+	recorder := &eventRecorder{statuses: []int{http.StatusTooManyRequests}}
+	client := newTestEventsClient(t, recorder, EventsClientConfig{})
+
+	// when
+	resp, err := client.Trigger(context.Background(), "routing-key", "", EventPayload{
+		Summary:  "disk full",
+		Source:   "db-1",
+		Severity: "critical",
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := recorder.received()
+	if len(events) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(events))
+	}
+	if events[0].DedupKey != events[1].DedupKey || resp.DedupKey != events[0].DedupKey {
+		t.Errorf("dedup keys differ: sent %q and %q, returned %q", events[0].DedupKey, events[1].DedupKey, resp.DedupKey)
+	}
+}
+
+func TestEventsClient_ResolveAlert_UsesAndClearsStoredDedupKey(t *testing.T) {
+	t.Parallel()
+
+	// given
+	store := NewMemoryDedupKeyStore()
+	recorder := &eventRecorder{}
+	client := newTestEventsClient(t, recorder, EventsClientConfig{DedupKeyStore: store})
+	ctx := context.Background()
+
+	triggered, err := client.TriggerAlert(ctx, "routing-key", "disk-full/db-1", EventPayload{
+		Summary:  "disk full",
+		Source:   "db-1",
+		Severity: "critical",
+	})
+	if err != nil {
+		t.Fatalf("trigger: unexpected error: %v", err)
+	}
+
+	// when
+	_, err = client.ResolveAlert(ctx, "routing-key", "disk-full/db-1")
+
+	// then
+	if err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+
+	events := recorder.received()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[1].Action != EventActionResolve || events[1].DedupKey != triggered.DedupKey {
+		t.Errorf("got %s event with dedup key %q, want resolve with %q", events[1].Action, events[1].DedupKey, triggered.DedupKey)
+	}
+
+	if _, err := store.Get("disk-full/db-1"); !errors.Is(err, ErrDedupKeyNotFound) {
+		t.Errorf("got %v from the store after resolving, want ErrDedupKeyNotFound", err)
+	}
+	if _, err := client.ResolveAlert(ctx, "routing-key", "disk-full/db-1"); !errors.Is(err, ErrDedupKeyNotFound) {
+		t.Errorf("got %v resolving again, want ErrDedupKeyNotFound", err)
+	}
+	if got := len(recorder.received()); got != 2 {
+		t.Errorf("got %d events after resolving again, want no new event", got)
+	}
+}
+
+// putGetStore is a DedupKeyStore without a Delete method.
+type putGetStore struct {
+	store *MemoryDedupKeyStore
+}
+
+func (s putGetStore) Put(alertKey, dedupKey string) error { return s.store.Put(alertKey, dedupKey) }
+
+func (s putGetStore) Get(alertKey string) (string, error) { return s.store.Get(alertKey) }
+
+func TestEventsClient_ResolveAlert_KeepsKeyInStoreWithoutDelete(t *testing.T) {
+	t.Parallel()
+
+	// given
+	store := putGetStore{store: NewMemoryDedupKeyStore()}
+	recorder := &eventRecorder{}
+	client := newTestEventsClient(t, recorder, EventsClientConfig{DedupKeyStore: store})
+	ctx := context.Background()
+
+	triggered, err := client.TriggerAlert(ctx, "routing-key", "disk-full/db-1", EventPayload{
+		Summary:  "disk full",
+		Source:   "db-1",
+		Severity: "critical",
+	})
+	if err != nil {
+		t.Fatalf("trigger: unexpected error: %v", err)
+	}
+
+	// when
+	_, err = client.ResolveAlert(ctx, "routing-key", "disk-full/db-1")
+
+	// then
+	if err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+	if got, err := store.Get("disk-full/db-1"); err != nil || got != triggered.DedupKey {
+		t.Errorf("got %q, %v from the store after resolving, want %q", got, err, triggered.DedupKey)
+	}
+}
+
+func TestEventsClient_Send_LogsEachRetry(t *testing.T) {
+	t.Parallel()
+
+	// given
+	var logs logRecorder
+	recorder := &eventRecorder{statuses: []int{http.StatusTooManyRequests}}
+	client := newTestEventsClient(t, recorder, EventsClientConfig{Logger: logs.logger()})
+
+	// when
+	_, err := client.Trigger(context.Background(), "routing-key", "", EventPayload{
+		Summary:  "disk full",
+		Source:   "db-1",
+		Severity: "critical",
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records := logs.records(t)
+	if len(records) != 1 {
+		t.Fatalf("got %d log records, want 1", len(records))
+	}
+	if records[0]["attempt"] != float64(1) || records[0]["status"] != float64(http.StatusTooManyRequests) {
+		t.Errorf("got record %v, want attempt 1 with status 429", records[0])
+	}
+	if _, ok := records[0]["backoff"]; !ok {
+		t.Errorf("got record %v, want a backoff", records[0])
+	}
+}
+
+func TestEventsClient_ValidateRoutingKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  error
+		wantSent []string
+	}{
+		{
+			name:     "valid key",
+			wantSent: []string{EventActionTrigger, EventActionResolve},
+		},
+		{
+			name:     "invalid key",
+			statuses: []int{http.StatusBadRequest},
+			wantErr:  ErrInvalidRoutingKey,
+			wantSent: []string{EventActionTrigger},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// given
+			recorder := &eventRecorder{statuses: tt.statuses}
+			client := newTestEventsClient(t, recorder, EventsClientConfig{})
+
+			// when
+			err := client.ValidateRoutingKey(context.Background(), "routing-key")
+
+			// then
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			events := recorder.received()
+			var sent []string
+			for _, event := range events {
+				sent = append(sent, event.Action)
+			}
+			if !slices.Equal(sent, tt.wantSent) {
+				t.Errorf("sent %v, want %v", sent, tt.wantSent)
+			}
+			if len(events) == 2 && events[0].DedupKey != events[1].DedupKey {
+				t.Errorf("resolved dedup key %q, want the probe's %q", events[1].DedupKey, events[0].DedupKey)
+			}
+		})
+	}
+}
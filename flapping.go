@@ -0,0 +1,110 @@
+package pagerduty
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	transform "github.com/resolute-sh/resolute-transform"
+)
+
+const (
+	defaultFlapWindow   = time.Hour
+	defaultFlapMinCount = 3
+)
+
+// detectFlapping groups short-lived incidents into per-service bursts. An
+// incident is short-lived when it resolved within window of being created.
+// A burst starts at a short-lived incident and takes in every later one on
+// the same service created within window of it; bursts smaller than
+// minCount are not reported.
+func detectFlapping(incidents []Incident, window time.Duration, minCount int) [][]Incident {
+	if window <= 0 {
+		window = defaultFlapWindow
+	}
+	if minCount <= 0 {
+		minCount = defaultFlapMinCount
+	}
+
+	byService := make(map[string][]Incident)
+	var serviceIDs []string
+	for _, incident := range incidents {
+		if incident.ResolvedAt == nil || incident.ResolvedAt.Sub(incident.CreatedAt) > window {
+			continue
+		}
+		if _, ok := byService[incident.Service.ID]; !ok {
+			serviceIDs = append(serviceIDs, incident.Service.ID)
+		}
+		byService[incident.Service.ID] = append(byService[incident.Service.ID], incident)
+	}
+
+	var groups [][]Incident
+	for _, serviceID := range serviceIDs {
+		short := byService[serviceID]
+		sort.SliceStable(short, func(i, j int) bool {
+			return short[i].CreatedAt.Before(short[j].CreatedAt)
+		})
+
+		for start := 0; start < len(short); {
+			end := start + 1
+			for end < len(short) && short[end].CreatedAt.Sub(short[start].CreatedAt) <= window {
+				end++
+			}
+			if end-start >= minCount {
+				groups = append(groups, short[start:end])
+			}
+			start = end
+		}
+	}
+
+	return groups
+}
+
+// flapGroupToDocument summarizes a burst of flapping incidents.
+func flapGroupToDocument(group []Incident) transform.Document {
+	first, last := group[0], group[len(group)-1]
+	service := first.Service
+	name := service.Name
+	if name == "" {
+		name = service.Summary
+	}
+
+	ids := make([]string, 0, len(group))
+	var lines []string
+	lines = append(lines, fmt.Sprintf("%s flapped %d times between %s and %s",
+		name, len(group), first.CreatedAt.Format(time.RFC3339), last.CreatedAt.Format(time.RFC3339)))
+	for _, incident := range group {
+		ids = append(ids, incident.ID)
+		lines = append(lines, fmt.Sprintf("- %s %s (resolved after %s)",
+			incident.CreatedAt.Format(time.RFC3339), incident.Summary, incident.ResolvedAt.Sub(incident.CreatedAt)))
+	}
+
+	updatedAt := last.UpdatedAt
+	for _, incident := range group {
+		if incident.UpdatedAt.After(updatedAt) {
+			updatedAt = incident.UpdatedAt
+		}
+	}
+
+	metadata := map[string]string{
+		"document_type": "flapping",
+		"service":       name,
+		"service_id":    service.ID,
+		"flap_count":    strconv.Itoa(len(group)),
+		"first_at":      first.CreatedAt.Format(time.RFC3339),
+		"last_at":       last.CreatedAt.Format(time.RFC3339),
+		"incident_ids":  strings.Join(ids, ","),
+	}
+
+	return transform.Document{
+		ID:        fmt.Sprintf("flapping-%s-%d", service.ID, first.CreatedAt.Unix()),
+		Content:   strings.Join(lines, "\n"),
+		Title:     fmt.Sprintf("%s flapping (%d incidents)", name, len(group)),
+		Source:    "pagerduty",
+		URL:       first.HTMLURL,
+		Metadata:  metadata,
+		UpdatedAt: updatedAt,
+	}
+}
@@ -0,0 +1,105 @@
+package pagerduty
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchIncidentsActivity_CollapsesFlappingBurst(t *testing.T) {
+	// given
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	api := Service{ID: "PS1", Name: "API"}
+
+	var incidents []Incident
+	for i := 0; i < 4; i++ {
+		created := start.Add(time.Duration(i) * 10 * time.Minute)
+		resolved := created.Add(2 * time.Minute)
+		incidents = append(incidents, Incident{
+			ID:         fmt.Sprintf("P%d", i+1),
+			Status:     StatusResolved,
+			CreatedAt:  created,
+			ResolvedAt: &resolved,
+			Service:    api,
+		})
+	}
+	longResolved := start.Add(5 * time.Hour)
+	incidents = append(incidents,
+		Incident{ID: "P5", Status: StatusResolved, CreatedAt: start, ResolvedAt: &longResolved, Service: api},
+		Incident{ID: "P6", Status: StatusTriggered, CreatedAt: start, Service: Service{ID: "PS2", Name: "DB"}},
+	)
+	useTestAPI(t, listIncidents(t, incidents...))
+
+	// when
+	_, docs := fetchIncidentDocs(t, FetchIncidentsInput{CollapseFlapping: true})
+
+	// then
+	byID := docsByID(docs)
+	if len(byID) != 3 {
+		t.Fatalf("got %d documents, want 3", len(byID))
+	}
+	for _, id := range []string{"P5", "P6"} {
+		if _, ok := byID[id]; !ok {
+			t.Errorf("missing document for %s", id)
+		}
+	}
+
+	flap, ok := byID[fmt.Sprintf("flapping-PS1-%d", start.Unix())]
+	if !ok {
+		t.Fatalf("missing flapping document, got %v", docs)
+	}
+	if got := flap.Metadata["flap_count"]; got != "4" {
+		t.Errorf("got flap_count %q, want 4", got)
+	}
+	if got := flap.Metadata["incident_ids"]; got != "P1,P2,P3,P4" {
+		t.Errorf("got incident_ids %q, want P1,P2,P3,P4", got)
+	}
+}
+
+func TestDetectFlapping_IgnoresBurstsBelowMinCount(t *testing.T) {
+	t.Parallel()
+
+	// given
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	resolved := start.Add(time.Minute)
+	incidents := []Incident{
+		{ID: "P1", CreatedAt: start, ResolvedAt: &resolved, Service: Service{ID: "PS1"}},
+		{ID: "P2", CreatedAt: start, ResolvedAt: &resolved, Service: Service{ID: "PS1"}},
+	}
+
+	// when
+	groups := detectFlapping(incidents, time.Hour, 3)
+
+	// then
+	if len(groups) != 0 {
+		t.Errorf("got %d bursts, want none", len(groups))
+	}
+}
+
+func TestFlapGroupToDocument_FallsBackToServiceSummary(t *testing.T) {
+	t.Parallel()
+
+	// given
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	resolved := start.Add(time.Minute)
+	service := Service{ID: "PS1", Summary: "API"}
+	group := []Incident{
+		{ID: "P1", CreatedAt: start, ResolvedAt: &resolved, Service: service},
+		{ID: "P2", CreatedAt: start.Add(time.Minute), ResolvedAt: &resolved, Service: service},
+	}
+
+	// when
+	doc := flapGroupToDocument(group)
+
+	// then
+	if doc.Title != "API flapping (2 incidents)" {
+		t.Errorf("got title %q, want %q", doc.Title, "API flapping (2 incidents)")
+	}
+	if got := doc.Metadata["service"]; got != "API" {
+		t.Errorf("got service %q, want API", got)
+	}
+	if !strings.HasPrefix(doc.Content, "API flapped 2 times") {
+		t.Errorf("content %q does not name the service", doc.Content)
+	}
+}
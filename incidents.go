@@ -2,7 +2,11 @@ package pagerduty
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,57 +16,439 @@ import (
 
 // FetchIncidentsInput is the input for FetchIncidentsActivity.
 type FetchIncidentsInput struct {
-	APIKey string
-	Since  *time.Time
-	Until  *time.Time
-	Limit  int
+	APIKey    string
+	SecretRef string
+	Since     *time.Time
+	Until     *time.Time
+	Limit     int
+
+	// OpenEndedUntil leaves Until unset when only Since is given, so the
+	// query runs up to whenever PagerDuty serves it. By default Until is
+	// pinned to the activity's start time, giving the window a fixed upper
+	// bound that is reported in the output, so a run can be reproduced.
+	OpenEndedUntil bool
+
+	// Statuses restricts results to incidents in these statuses.
+	Statuses []string
+
+	// ActiveOnly restricts results to triggered and acknowledged incidents.
+	// It is shorthand for the common case and cannot be combined with
+	// Statuses.
+	ActiveOnly bool
+
+	// IncludeServices expands each incident's service so its current
+	// status is recorded as service_status metadata.
+	IncludeServices bool
+
+	// IncludeAssignees expands assignees inline so their names are
+	// available even when PagerDuty would otherwise return references.
+	IncludeAssignees bool
+
+	// MaxRetryAfter fails fast with ErrRateLimited when PagerDuty asks to
+	// wait longer than this. Zero means no cap.
+	MaxRetryAfter time.Duration
+
+	// Priority orders this activity's requests against other activities
+	// sharing a PriorityRateLimiter set with SetRateLimiter.
+	Priority int
+
+	// LookupServices fetches each referenced service to record its
+	// service_status. Incidents whose service has since been deleted are
+	// kept and marked service_deleted. Unlike IncludeServices this costs one
+	// request per distinct service.
+	LookupServices bool
+
+	// IncludeResolvedBy reads each resolved incident's log to record who
+	// resolved it as resolved_by and resolved_by_type metadata. This costs
+	// at least one extra request per resolved incident.
+	IncludeResolvedBy bool
+
+	// IncludeResolvedByEmail additionally looks up resolved_by_email for
+	// incidents resolved by a user.
+	IncludeResolvedByEmail bool
+
+	// IncludeAutoResolved reads each resolved incident's log to record
+	// auto_resolved, distinguishing incidents closed by their monitoring
+	// integration or service from those closed by a responder.
+	IncludeAutoResolved bool
+
+	// IncludeTimeToFirstNote reads each incident's notes to record
+	// seconds_to_first_note, a proxy for how quickly responders engaged.
+	// This costs one extra request per incident.
+	IncludeTimeToFirstNote bool
+
+	// IntegrationIDs keeps only incidents with at least one alert from one
+	// of these integrations, e.g. to ingest Datadog-sourced incidents but
+	// not CloudWatch ones on the same service. Filtering reads every
+	// incident's alerts (one or more requests each) and happens after the
+	// page is fetched, so Count may be lower than Limit.
+	IntegrationIDs []string
+
+	// EscalatedOnly keeps only incidents that escalated beyond the first
+	// level of their escalation policy, stamping max_escalation_level.
+	// Incidents handled at level 1, including those resolved there, are
+	// dropped. It reads every incident's log, so Count may be lower than
+	// Limit.
+	EscalatedOnly bool
+
+	// IncludeRelated looks up each incident's related incidents and records
+	// related_incidents and related_incident_types metadata. IngestRelated
+	// additionally stores a document for each related incident not already
+	// in the results, with related_to and relationship metadata, so
+	// clusters of incidents can be analysed together. Each costs one extra
+	// request per incident.
+	IncludeRelated bool
+	IngestRelated  bool
+
+	// EnrichConcurrency bounds how many incidents are enriched at once.
+	// Defaults to 4.
+	EnrichConcurrency int
+
+	// IncludeMetadata keeps only these metadata keys on each document.
+	// ExcludeMetadata drops these keys instead. At most one may be set;
+	// by default all metadata is kept.
+	IncludeMetadata []string
+	ExcludeMetadata []string
+
+	// ContentSections selects which sections make up each document's
+	// content, in order (see the Section constants). Defaults to summary
+	// then description. Notes and alerts are fetched per incident when
+	// requested.
+	ContentSections []string
+
+	// DedupeAlerts lists identical alert summaries in the alerts section
+	// once, followed by how many times they occurred, e.g. "(×37)". It
+	// requires ContentSections to include SectionAlerts.
+	DedupeAlerts bool
+
+	// CollapseFlapping replaces bursts of short-lived incidents on the same
+	// service with a single summary document carrying a flap_count. An
+	// incident is part of a burst when it was resolved within FlapWindow
+	// of being triggered, and at least FlapMinCount such incidents began
+	// within FlapWindow of the first. FlapWindow defaults to one hour and
+	// FlapMinCount to 3.
+	CollapseFlapping bool
+	FlapWindow       time.Duration
+	FlapMinCount     int
+
+	// EmitSummary stores an additional document with aggregate statistics
+	// for the fetched incidents, returned separately in SummaryRef.
+	EmitSummary bool
+
+	// RequestTotal asks PagerDuty to compute the total number of matching
+	// incidents. Without it, Total is only known when every match fits in
+	// one page and is otherwise reported as UnknownTotal.
+	RequestTotal bool
+
+	// BucketBy stores documents in a separate ref per escalation policy,
+	// service or team (see the BucketBy constants), returned in Refs.
+	// By default all documents are stored in the single Ref.
+	BucketBy string
 }
 
+// Values for FetchIncidentsInput.BucketBy.
+const (
+	BucketByEscalationPolicy = "escalation_policy"
+	BucketByService          = "service"
+	BucketByTeam             = "team"
+)
+
+// UnknownTotal is reported as FetchIncidentsOutput.Total when more incidents
+// match than were fetched but PagerDuty was not asked for the total.
+const UnknownTotal = -1
+
 // FetchIncidentsOutput is the output of FetchIncidentsActivity.
 type FetchIncidentsOutput struct {
 	Ref   core.DataRef
 	Count int
+
+	// Total is the number of incidents matching the query, or UnknownTotal.
 	Total int
+
+	// SummaryRef holds the summary document when EmitSummary is set.
+	SummaryRef core.DataRef
+
+	// Refs maps bucket IDs to their stored documents when BucketBy is set,
+	// in which case Ref is left empty. Incidents without a team are placed
+	// in the "" bucket when bucketing by team.
+	Refs map[string]core.DataRef
+
+	// Until is the upper bound of the query window, including one defaulted
+	// to the activity's start time. It is nil for open-ended queries.
+	Until *time.Time
 }
 
 // FetchIncidentsActivity fetches incidents from PagerDuty and stores them.
 func FetchIncidentsActivity(ctx context.Context, input FetchIncidentsInput) (FetchIncidentsOutput, error) {
-	client := NewClient(ClientConfig{
-		APIKey: input.APIKey,
+	if input.Since != nil && input.Until == nil && !input.OpenEndedUntil {
+		now := time.Now().UTC()
+		input.Until = &now
+	}
+
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey:        input.APIKey,
+		MaxRetryAfter: input.MaxRetryAfter,
+		Priority:      input.Priority,
 	})
+	if err != nil {
+		return FetchIncidentsOutput{}, err
+	}
 
 	limit := input.Limit
 	if limit <= 0 {
 		limit = 100
 	}
 
-	result, err := client.ListIncidents(ctx, input.Since, input.Until, limit)
+	statuses, err := incidentStatuses(input.Statuses, input.ActiveOnly)
+	if err != nil {
+		return FetchIncidentsOutput{}, err
+	}
+
+	if len(input.IncludeMetadata) > 0 && len(input.ExcludeMetadata) > 0 {
+		return FetchIncidentsOutput{}, errors.New("IncludeMetadata and ExcludeMetadata are mutually exclusive")
+	}
+
+	if err := validateContentSections(input.ContentSections); err != nil {
+		return FetchIncidentsOutput{}, err
+	}
+
+	if input.DedupeAlerts && !slices.Contains(input.ContentSections, SectionAlerts) {
+		return FetchIncidentsOutput{}, errors.New("DedupeAlerts requires the alerts content section")
+	}
+
+	switch input.BucketBy {
+	case "", BucketByEscalationPolicy, BucketByService, BucketByTeam:
+	default:
+		return FetchIncidentsOutput{}, fmt.Errorf("unknown BucketBy %q", input.BucketBy)
+	}
+
+	var include []string
+	if input.IncludeServices {
+		include = append(include, IncludeServices)
+	}
+	if input.IncludeAssignees {
+		include = append(include, IncludeAssignees)
+	}
+
+	result, err := client.ListIncidentsWithOptions(ctx, ListIncidentsOptions{
+		Since:    input.Since,
+		Until:    input.Until,
+		Limit:    limit,
+		Statuses: statuses,
+		Include:  include,
+		Total:    input.RequestTotal,
+	})
 	if err != nil {
 		return FetchIncidentsOutput{}, fmt.Errorf("list incidents: %w", err)
 	}
 
+	sortIncidents(result.Incidents)
+
+	total := result.Total
+	if !input.RequestTotal {
+		total = UnknownTotal
+		if !result.More {
+			total = len(result.Incidents)
+		}
+	}
+
+	var enrichers []enrichFunc
+	if len(input.IntegrationIDs) > 0 {
+		enrichers = append(enrichers, integrationFilter(client, input.IntegrationIDs))
+	}
+	if input.EscalatedOnly {
+		enrichers = append(enrichers, escalationFilter(client))
+	}
+	if input.LookupServices {
+		enrichers = append(enrichers, serviceEnricher(client))
+	}
+	if input.IncludeResolvedBy || input.IncludeResolvedByEmail || input.IncludeAutoResolved {
+		enrichers = append(enrichers, resolutionEnricher(client, resolutionOptions{
+			ResolvedBy:      input.IncludeResolvedBy,
+			ResolvedByEmail: input.IncludeResolvedByEmail,
+			AutoResolved:    input.IncludeAutoResolved,
+		}))
+	}
+	if input.IncludeTimeToFirstNote {
+		enrichers = append(enrichers, timeToFirstNoteEnricher(client))
+	}
+	if input.IncludeRelated || input.IngestRelated {
+		enrichers = append(enrichers, relatedEnricher(client))
+	}
+	if len(input.ContentSections) > 0 {
+		enrichers = append(enrichers, contentEnricher(client, input.ContentSections))
+	}
+
+	enrichments, err := enrichIncidents(ctx, result.Incidents, input.EnrichConcurrency, enrichers)
+	if err != nil {
+		return FetchIncidentsOutput{}, err
+	}
+
+	kept := make([]Incident, 0, len(result.Incidents))
+	for i, incident := range result.Incidents {
+		if !enrichments[i].Exclude {
+			kept = append(kept, incident)
+		}
+	}
+
+	var flapGroups [][]Incident
+	flapping := make(map[string]bool)
+	if input.CollapseFlapping {
+		flapGroups = detectFlapping(kept, input.FlapWindow, input.FlapMinCount)
+		for _, group := range flapGroups {
+			for _, incident := range group {
+				flapping[incident.ID] = true
+			}
+		}
+	}
+
 	docs := make([]transform.Document, 0, len(result.Incidents))
-	for _, incident := range result.Incidents {
-		doc := incidentToDocument(incident)
+	bucketKeys := make([]string, 0, len(result.Incidents))
+	addDocument := func(doc transform.Document, incident Incident) {
+		doc.Metadata = projectMetadata(doc.Metadata, input.IncludeMetadata, input.ExcludeMetadata)
 		docs = append(docs, doc)
+		bucketKeys = append(bucketKeys, bucketKey(incident, input.BucketBy))
 	}
 
-	ref, err := transform.StoreDocuments(ctx, docs)
-	if err != nil {
-		return FetchIncidentsOutput{}, fmt.Errorf("store documents: %w", err)
+	for i, incident := range result.Incidents {
+		if enrichments[i].Exclude || flapping[incident.ID] {
+			continue
+		}
+
+		doc := incidentToDocument(incident)
+		if len(input.ContentSections) > 0 {
+			doc.Content = assembleContent(incident, input.ContentSections, enrichments[i], input.DedupeAlerts)
+		}
+		for k, v := range enrichments[i].Metadata {
+			doc.Metadata[k] = v
+		}
+		addDocument(doc, incident)
 	}
 
-	return FetchIncidentsOutput{
-		Ref:   ref,
+	for _, group := range flapGroups {
+		addDocument(flapGroupToDocument(group), group[0])
+	}
+
+	if input.IngestRelated {
+		seen := make(map[string]bool, len(result.Incidents))
+		for _, incident := range result.Incidents {
+			seen[incident.ID] = true
+		}
+		for i, incident := range result.Incidents {
+			if enrichments[i].Exclude {
+				continue
+			}
+			for _, related := range enrichments[i].Related {
+				if seen[related.Incident.ID] {
+					continue
+				}
+				seen[related.Incident.ID] = true
+
+				doc := incidentToDocument(related.Incident)
+				doc.Metadata["related_to"] = incident.ID
+				doc.Metadata["relationship"] = relationshipType(related)
+				addDocument(doc, related.Incident)
+			}
+		}
+	}
+
+	if err := postProcessDocuments(ctx, docs); err != nil {
+		return FetchIncidentsOutput{}, err
+	}
+
+	output := FetchIncidentsOutput{
 		Count: len(docs),
-		Total: result.Total,
-	}, nil
+		Total: total,
+		Until: input.Until,
+	}
+
+	if input.EmitSummary {
+		summary := []transform.Document{incidentSummaryToDocument(kept, input.Since, input.Until)}
+		if err := postProcessDocuments(ctx, summary); err != nil {
+			return FetchIncidentsOutput{}, err
+		}
+		ref, err := transform.StoreDocuments(ctx, summary)
+		if err != nil {
+			return FetchIncidentsOutput{}, fmt.Errorf("store summary document: %w", err)
+		}
+		output.SummaryRef = ref
+	}
+
+	if input.BucketBy == "" {
+		ref, err := transform.StoreDocuments(ctx, docs)
+		if err != nil {
+			return FetchIncidentsOutput{}, fmt.Errorf("store documents: %w", err)
+		}
+		output.Ref = ref
+		return output, nil
+	}
+
+	buckets := make(map[string][]transform.Document)
+	for i, doc := range docs {
+		buckets[bucketKeys[i]] = append(buckets[bucketKeys[i]], doc)
+	}
+
+	output.Refs = make(map[string]core.DataRef, len(buckets))
+	for key, bucket := range buckets {
+		ref, err := transform.StoreDocuments(ctx, bucket)
+		if err != nil {
+			return FetchIncidentsOutput{}, fmt.Errorf("store documents for %s %q: %w", input.BucketBy, key, err)
+		}
+		output.Refs[key] = ref
+	}
+
+	return output, nil
+}
+
+// sortIncidents orders incidents by creation time, breaking ties between
+// incidents created in the same second by incident number, so results are
+// in a stable order even for bursts that PagerDuty may return in any order.
+func sortIncidents(incidents []Incident) {
+	sort.SliceStable(incidents, func(i, j int) bool {
+		a, b := incidents[i], incidents[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.IncidentNumber < b.IncidentNumber
+	})
+}
+
+// bucketKey returns the ID of the bucket an incident belongs to.
+func bucketKey(incident Incident, bucketBy string) string {
+	switch bucketBy {
+	case BucketByEscalationPolicy:
+		return incident.EscalationPolicy.ID
+	case BucketByService:
+		return incident.Service.ID
+	case BucketByTeam:
+		if len(incident.Teams) > 0 {
+			return incident.Teams[0].ID
+		}
+	}
+	return ""
 }
 
 // FetchIncidentInput is the input for FetchIncidentActivity.
 type FetchIncidentInput struct {
 	APIKey     string
+	SecretRef  string
 	IncidentID string
+
+	// IncludeServices expands the incident's service so its current
+	// status is recorded as service_status metadata.
+	IncludeServices bool
+
+	// IncludeAssignees expands assignees inline so their names are
+	// available even when PagerDuty would otherwise return references.
+	IncludeAssignees bool
+
+	// MaxRetryAfter fails fast with ErrRateLimited when PagerDuty asks to
+	// wait longer than this. Zero means no cap.
+	MaxRetryAfter time.Duration
+
+	// Priority orders this activity's requests against other activities
+	// sharing a PriorityRateLimiter set with SetRateLimiter.
+	Priority int
 }
 
 // FetchIncidentOutput is the output of FetchIncidentActivity.
@@ -73,26 +459,45 @@ type FetchIncidentOutput struct {
 
 // FetchIncidentActivity fetches a single incident by ID.
 func FetchIncidentActivity(ctx context.Context, input FetchIncidentInput) (FetchIncidentOutput, error) {
-	client := NewClient(ClientConfig{
-		APIKey: input.APIKey,
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey:        input.APIKey,
+		MaxRetryAfter: input.MaxRetryAfter,
+		Priority:      input.Priority,
 	})
+	if err != nil {
+		return FetchIncidentOutput{}, err
+	}
+
+	var include []string
+	if input.IncludeServices {
+		include = append(include, IncludeServices)
+	}
+	if input.IncludeAssignees {
+		include = append(include, IncludeAssignees)
+	}
 
-	incident, err := client.GetIncident(ctx, input.IncidentID)
+	incident, err := client.GetIncident(ctx, input.IncidentID, include...)
 	if err != nil {
 		return FetchIncidentOutput{}, fmt.Errorf("get incident: %w", err)
 	}
 
+	docs := []transform.Document{incidentToDocument(*incident)}
+	if err := postProcessDocuments(ctx, docs); err != nil {
+		return FetchIncidentOutput{}, err
+	}
+
 	return FetchIncidentOutput{
-		Document: incidentToDocument(*incident),
+		Document: docs[0],
 		Found:    true,
 	}, nil
 }
 
 // FetchPostmortemsInput is the input for FetchPostmortemsActivity.
 type FetchPostmortemsInput struct {
-	APIKey string
-	Since  *time.Time
-	Limit  int
+	APIKey    string
+	SecretRef string
+	Since     *time.Time
+	Limit     int
 }
 
 // FetchPostmortemsOutput is the output of FetchPostmortemsActivity.
@@ -103,9 +508,12 @@ type FetchPostmortemsOutput struct {
 
 // FetchPostmortemsActivity fetches postmortems from PagerDuty and stores them.
 func FetchPostmortemsActivity(ctx context.Context, input FetchPostmortemsInput) (FetchPostmortemsOutput, error) {
-	client := NewClient(ClientConfig{
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
 		APIKey: input.APIKey,
 	})
+	if err != nil {
+		return FetchPostmortemsOutput{}, err
+	}
 
 	limit := input.Limit
 	if limit <= 0 {
@@ -126,6 +534,10 @@ func FetchPostmortemsActivity(ctx context.Context, input FetchPostmortemsInput)
 		}
 	}
 
+	if err := postProcessDocuments(ctx, docs); err != nil {
+		return FetchPostmortemsOutput{}, err
+	}
+
 	ref, err := transform.StoreDocuments(ctx, docs)
 	if err != nil {
 		return FetchPostmortemsOutput{}, fmt.Errorf("store documents: %w", err)
@@ -137,6 +549,17 @@ func FetchPostmortemsActivity(ctx context.Context, input FetchPostmortemsInput)
 	}, nil
 }
 
+// incidentStatuses resolves the statuses filter for an incident listing.
+func incidentStatuses(statuses []string, activeOnly bool) ([]string, error) {
+	if !activeOnly {
+		return statuses, nil
+	}
+	if len(statuses) > 0 {
+		return nil, errors.New("ActiveOnly and Statuses are mutually exclusive")
+	}
+	return []string{StatusTriggered, StatusAcknowledged}, nil
+}
+
 func incidentToDocument(incident Incident) transform.Document {
 	var contentParts []string
 	contentParts = append(contentParts, incident.Summary)
@@ -154,16 +577,37 @@ func incidentToDocument(incident Incident) transform.Document {
 		"service":     incident.Service.Name,
 	}
 
+	if incident.Self != "" {
+		metadata["api_url"] = incident.Self
+	}
+
+	if incident.HTMLURL != "" {
+		metadata["timeline_url"] = strings.TrimSuffix(incident.HTMLURL, "/") + "/timeline"
+	}
+
+	if incident.IncidentNumber != 0 {
+		metadata["incident_number"] = strconv.Itoa(incident.IncidentNumber)
+	}
+
+	if normalize := statusNormalizer(); normalize != nil {
+		metadata["raw_status"] = incident.Status
+		metadata["status"] = normalize(incident.Status)
+	}
+
 	if incident.Priority != nil {
 		metadata["priority"] = incident.Priority.Name
 	}
 
+	if incident.Service.Status != "" {
+		metadata["service_status"] = incident.Service.Status
+	}
+
 	if len(incident.Assignments) > 0 {
-		metadata["assignee"] = incident.Assignments[0].Assignee.Name
+		metadata["assignee"] = incident.Assignments[0].Assignee.DisplayName()
 	}
 
 	return transform.Document{
-		ID:        incident.ID,
+		ID:        documentKey(incident),
 		Content:   content,
 		Title:     incident.Summary,
 		Source:    "pagerduty",
@@ -173,6 +617,25 @@ func incidentToDocument(incident Incident) transform.Document {
 	}
 }
 
+// projectMetadata returns metadata restricted to the include keys, or with
+// the exclude keys removed. With neither set it is returned unchanged.
+func projectMetadata(metadata map[string]string, include, exclude []string) map[string]string {
+	if len(include) > 0 {
+		projected := make(map[string]string, len(include))
+		for _, key := range include {
+			if v, ok := metadata[key]; ok {
+				projected[key] = v
+			}
+		}
+		return projected
+	}
+
+	for _, key := range exclude {
+		delete(metadata, key)
+	}
+	return metadata
+}
+
 // FetchIncidents creates a node for fetching PagerDuty incidents.
 func FetchIncidents(input FetchIncidentsInput) *core.Node[FetchIncidentsInput, FetchIncidentsOutput] {
 	return core.NewNode("pagerduty.FetchIncidents", FetchIncidentsActivity, input)
@@ -0,0 +1,448 @@
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	transform "github.com/resolute-sh/resolute-transform"
+)
+
+func TestSortIncidents(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		incidents []Incident
+		want      []string
+	}{
+		{
+			name: "orders by creation time",
+			incidents: []Incident{
+				{ID: "P2", IncidentNumber: 1, CreatedAt: base.Add(time.Minute)},
+				{ID: "P1", IncidentNumber: 2, CreatedAt: base},
+			},
+			want: []string{"P1", "P2"},
+		},
+		{
+			name: "breaks same-second ties by incident number",
+			incidents: []Incident{
+				{ID: "P3", IncidentNumber: 103, CreatedAt: base},
+				{ID: "P1", IncidentNumber: 101, CreatedAt: base},
+				{ID: "P2", IncidentNumber: 102, CreatedAt: base},
+			},
+			want: []string{"P1", "P2", "P3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// when
+			sortIncidents(tt.incidents)
+
+			// then
+			for i, incident := range tt.incidents {
+				if incident.ID != tt.want[i] {
+					t.Fatalf("got %s at position %d, want %s", incident.ID, i, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIncidentToDocument_ServiceStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       string
+		wantStatus string
+	}{
+		{
+			name:       "included service",
+			json:       `{"id":"P1","service":{"id":"PS1","type":"service","name":"API","status":"critical"}}`,
+			wantStatus: "critical",
+		},
+		{
+			name: "service reference",
+			json: `{"id":"P1","service":{"id":"PS1","type":"service_reference","summary":"API"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			var incident Incident
+			if err := json.Unmarshal([]byte(tt.json), &incident); err != nil {
+				t.Fatalf("decode incident: %v", err)
+			}
+
+			// when
+			doc := incidentToDocument(incident)
+
+			// then
+			status, ok := doc.Metadata["service_status"]
+			if tt.wantStatus == "" {
+				if ok {
+					t.Errorf("got service_status %q, want none", status)
+				}
+				return
+			}
+			if status != tt.wantStatus {
+				t.Errorf("got service_status %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestFetchIncidentsActivity_ActiveOnlySendsActiveStatuses(t *testing.T) {
+	// given
+	var statuses []string
+	useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statuses = r.URL.Query()["statuses[]"]
+		writeJSON(t, w, IncidentListResponse{})
+	}))
+
+	// when
+	_, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{ActiveOnly: true})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(statuses, []string{StatusTriggered, StatusAcknowledged}) {
+		t.Errorf("got statuses %v, want [triggered acknowledged]", statuses)
+	}
+}
+
+func TestFetchIncidentsActivity_RejectsActiveOnlyWithStatuses(t *testing.T) {
+	// when
+	_, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{
+		ActiveOnly: true,
+		Statuses:   []string{StatusResolved},
+	})
+
+	// then
+	if err == nil {
+		t.Fatal("expected an error combining ActiveOnly with Statuses")
+	}
+}
+
+// listIncidents answers every request with incidents as a single page.
+func listIncidents(t *testing.T, incidents ...Incident) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, IncidentListResponse{Incidents: incidents})
+	})
+}
+
+// fetchIncidentDocs runs FetchIncidentsActivity and loads the stored
+// documents.
+func fetchIncidentDocs(t *testing.T, input FetchIncidentsInput) (FetchIncidentsOutput, []transform.Document) {
+	t.Helper()
+
+	output, err := FetchIncidentsActivity(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return output, loadDocs(t, output.Ref)
+}
+
+func TestFetchIncidentsActivity_IncludeMetadataKeepsOnlyRequestedKeys(t *testing.T) {
+	// given
+	useTestAPI(t, listIncidents(t, Incident{
+		ID:       "P1",
+		Status:   StatusTriggered,
+		Urgency:  "high",
+		Service:  Service{Name: "API"},
+		Priority: &Priority{Name: "P1"},
+	}))
+
+	// when
+	_, docs := fetchIncidentDocs(t, FetchIncidentsInput{
+		IncludeMetadata: []string{"incident_id", "status", "not_a_key"},
+	})
+
+	// then
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	want := map[string]string{"incident_id": "P1", "status": StatusTriggered}
+	if !maps.Equal(docs[0].Metadata, want) {
+		t.Errorf("got metadata %v, want %v", docs[0].Metadata, want)
+	}
+}
+
+func TestFetchIncidentsActivity_ExcludeMetadataDropsKeys(t *testing.T) {
+	// given
+	useTestAPI(t, listIncidents(t, Incident{ID: "P1", Status: StatusTriggered, Urgency: "high"}))
+
+	// when
+	_, docs := fetchIncidentDocs(t, FetchIncidentsInput{
+		ExcludeMetadata: []string{"urgency", "api_url"},
+	})
+
+	// then
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	for _, key := range []string{"urgency", "api_url"} {
+		if _, ok := docs[0].Metadata[key]; ok {
+			t.Errorf("got %s in metadata, want it dropped", key)
+		}
+	}
+	if docs[0].Metadata["incident_id"] != "P1" {
+		t.Errorf("got incident_id %q, want P1", docs[0].Metadata["incident_id"])
+	}
+}
+
+func TestFetchIncidentsActivity_RejectsIncludeAndExcludeMetadata(t *testing.T) {
+	// when
+	_, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{
+		IncludeMetadata: []string{"status"},
+		ExcludeMetadata: []string{"urgency"},
+	})
+
+	// then
+	if err == nil {
+		t.Fatal("expected an error setting both IncludeMetadata and ExcludeMetadata")
+	}
+}
+
+func TestFetchIncidentsActivity_BucketByEscalationPolicy(t *testing.T) {
+	// given
+	useTestAPI(t, listIncidents(t,
+		Incident{ID: "P1", EscalationPolicy: EscalationPolicy{ID: "EP1"}},
+		Incident{ID: "P2", EscalationPolicy: EscalationPolicy{ID: "EP2"}},
+		Incident{ID: "P3", EscalationPolicy: EscalationPolicy{ID: "EP1"}},
+	))
+
+	// when
+	output, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{
+		BucketBy: BucketByEscalationPolicy,
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output.Refs) != 2 {
+		t.Fatalf("got %d buckets, want 2", len(output.Refs))
+	}
+
+	want := map[string][]string{"EP1": {"P1", "P3"}, "EP2": {"P2"}}
+	for policy, wantIDs := range want {
+		ref, ok := output.Refs[policy]
+		if !ok {
+			t.Errorf("missing bucket %s", policy)
+			continue
+		}
+		var ids []string
+		for _, doc := range loadDocs(t, ref) {
+			ids = append(ids, doc.ID)
+		}
+		if !slices.Equal(ids, wantIDs) {
+			t.Errorf("bucket %s holds %v, want %v", policy, ids, wantIDs)
+		}
+	}
+}
+
+func TestFetchIncidentsActivity_RejectsUnknownBucketBy(t *testing.T) {
+	// when
+	_, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{BucketBy: "region"})
+
+	// then
+	if err == nil {
+		t.Fatal("expected an error for an unknown BucketBy")
+	}
+}
+
+func TestFetchIncidentsActivity_Total(t *testing.T) {
+	tests := []struct {
+		name         string
+		resp         IncidentListResponse
+		requestTotal bool
+		want         int
+	}{
+		{
+			name: "more pages without a requested total",
+			resp: IncidentListResponse{Incidents: []Incident{{ID: "P1"}}, More: true},
+			want: UnknownTotal,
+		},
+		{
+			name: "single page",
+			resp: IncidentListResponse{Incidents: []Incident{{ID: "P1"}, {ID: "P2"}}},
+			want: 2,
+		},
+		{
+			name:         "requested total",
+			resp:         IncidentListResponse{Incidents: []Incident{{ID: "P1"}}, More: true, Total: 40},
+			requestTotal: true,
+			want:         40,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, tt.resp)
+			}))
+
+			// when
+			output, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{
+				RequestTotal: tt.requestTotal,
+			})
+
+			// then
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output.Total != tt.want {
+				t.Errorf("got total %d, want %d", output.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncidentToDocument_URLs(t *testing.T) {
+	// given
+	incident := Incident{
+		ID:      "PABC123",
+		HTMLURL: "https://acme.eu.pagerduty.com/incidents/PABC123",
+		Self:    "https://api.eu.pagerduty.com/incidents/PABC123",
+	}
+
+	// when
+	doc := incidentToDocument(incident)
+
+	// then
+	if doc.URL != "https://acme.eu.pagerduty.com/incidents/PABC123" {
+		t.Errorf("got URL %q", doc.URL)
+	}
+	if got := doc.Metadata["api_url"]; got != "https://api.eu.pagerduty.com/incidents/PABC123" {
+		t.Errorf("got api_url %q", got)
+	}
+	if got := doc.Metadata["timeline_url"]; got != "https://acme.eu.pagerduty.com/incidents/PABC123/timeline" {
+		t.Errorf("got timeline_url %q", got)
+	}
+}
+
+func TestFetchIncidentsActivity_APIURLFallsBackToClientEndpoint(t *testing.T) {
+	// given
+	srv := useTestAPI(t, listIncidents(t,
+		Incident{ID: "P1"},
+		Incident{ID: "P2", Self: "https://api.eu.pagerduty.com/incidents/P2"},
+	))
+
+	// when
+	_, docs := fetchIncidentDocs(t, FetchIncidentsInput{})
+
+	// then
+	byID := docsByID(docs)
+	if got, want := byID["P1"].Metadata["api_url"], srv.URL+"/incidents/P1"; got != want {
+		t.Errorf("got api_url %q for P1, want %q", got, want)
+	}
+	if got, want := byID["P2"].Metadata["api_url"], "https://api.eu.pagerduty.com/incidents/P2"; got != want {
+		t.Errorf("got api_url %q for P2, want %q", got, want)
+	}
+}
+
+func TestFetchIncidentsActivity_IncludeAssigneesHydratesNames(t *testing.T) {
+	// given
+	useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assignee := `{"id":"U1","type":"user_reference"}`
+		if slices.Contains(r.URL.Query()["include[]"], IncludeAssignees) {
+			assignee = `{"id":"U1","type":"user","name":"Alice Smith","email":"alice@example.com"}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"incidents":[{"id":"P1","assignments":[{"assignee":%s}]}]}`, assignee)
+	}))
+
+	tests := []struct {
+		name             string
+		includeAssignees bool
+		want             string
+	}{
+		{name: "references only", want: ""},
+		{name: "included assignees", includeAssignees: true, want: "Alice Smith"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			_, docs := fetchIncidentDocs(t, FetchIncidentsInput{IncludeAssignees: tt.includeAssignees})
+
+			// then
+			if len(docs) != 1 {
+				t.Fatalf("got %d documents, want 1", len(docs))
+			}
+			if got := docs[0].Metadata["assignee"]; got != tt.want {
+				t.Errorf("got assignee %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchIncidentsActivity_UntilDefaultsToStartTime(t *testing.T) {
+	// given
+	var query url.Values
+	useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeJSON(t, w, IncidentListResponse{})
+	}))
+	since := time.Now().Add(-time.Hour)
+
+	// when
+	before := time.Now().Truncate(time.Second)
+	output, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{Since: &since})
+	after := time.Now()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Until == nil {
+		t.Fatal("got no Until, want it defaulted to the start time")
+	}
+	if output.Until.Before(before) || output.Until.After(after) {
+		t.Errorf("got Until %s, want between %s and %s", output.Until, before, after)
+	}
+	if got, want := query.Get("until"), output.Until.UTC().Format(time.RFC3339); got != want {
+		t.Errorf("sent until %q, want %q", got, want)
+	}
+}
+
+func TestFetchIncidentsActivity_OpenEndedUntil(t *testing.T) {
+	// given
+	var query url.Values
+	useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeJSON(t, w, IncidentListResponse{})
+	}))
+	since := time.Now().Add(-time.Hour)
+
+	// when
+	output, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{
+		Since:          &since,
+		OpenEndedUntil: true,
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Until != nil {
+		t.Errorf("got Until %s, want none", output.Until)
+	}
+	if query.Has("until") {
+		t.Errorf("sent until %q, want none", query.Get("until"))
+	}
+}
@@ -0,0 +1,146 @@
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	transform "github.com/resolute-sh/resolute-transform"
+	"github.com/resolute-sh/resolute/core"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "resolute-pagerduty-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := useTestStorage(dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// useTestStorage stores documents under dir. GetStorage replaces the
+// global storage with a default under the working directory on its first
+// call, so that call is made from dir before the test storage is set.
+func useTestStorage(dir string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	_, err = core.GetStorage()
+	if chdirErr := os.Chdir(wd); chdirErr != nil {
+		return chdirErr
+	}
+	if err != nil {
+		return err
+	}
+
+	backend, err := core.NewLocalStorage(dir)
+	if err != nil {
+		return err
+	}
+	core.SetStorage(core.NewStorage(backend))
+	return nil
+}
+
+// useTestAPI points clients created by activities at a test server running
+// handler for the rest of the test.
+func useTestAPI(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	SetBaseURL(srv.URL)
+
+	t.Cleanup(func() {
+		SetBaseURL("")
+		srv.Close()
+	})
+
+	return srv
+}
+
+// writeJSON writes v as a JSON response body.
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Errorf("encode response: %v", err)
+	}
+}
+
+// loadDocs reads back the documents stored under ref.
+func loadDocs(t *testing.T, ref core.DataRef) []transform.Document {
+	t.Helper()
+
+	docs, err := transform.LoadDocuments(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("load documents: %v", err)
+	}
+	return docs
+}
+
+// docsByID indexes documents by ID.
+func docsByID(docs []transform.Document) map[string]transform.Document {
+	byID := make(map[string]transform.Document, len(docs))
+	for _, doc := range docs {
+		byID[doc.ID] = doc
+	}
+	return byID
+}
+
+// logRecorder collects the records written to a JSON slog handler.
+type logRecorder struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (r *logRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+// logger returns a logger writing to the recorder.
+func (r *logRecorder) logger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(r, nil))
+}
+
+// records decodes the recorded log records.
+func (r *logRecorder) records(t *testing.T) []map[string]interface{} {
+	t.Helper()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(r.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("decode log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,103 @@
+package pagerduty
+
+import (
+	"context"
+	"errors"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// BulkAddNotesInput is the input for BulkAddNotesActivity.
+type BulkAddNotesInput struct {
+	APIKey    string
+	SecretRef string
+
+	// From is the email of the PagerDuty user the notes are attributed to.
+	From string
+
+	IncidentIDs []string
+	Message     string
+
+	// Concurrency bounds how many notes are posted at once. Defaults to 4.
+	Concurrency int
+
+	// MaxFailures stops posting once more than this many notes have
+	// failed. Zero means no limit.
+	MaxFailures int
+}
+
+// BulkAddNotesOutput is the output of BulkAddNotesActivity.
+type BulkAddNotesOutput struct {
+	Succeeded []string
+	Failed    []IncidentFailure
+
+	// Skipped lists incidents that were not attempted because MaxFailures
+	// was exceeded.
+	Skipped []string
+
+	// ThresholdExceeded reports that more than MaxFailures notes failed and
+	// the remaining incidents were skipped.
+	ThresholdExceeded bool
+}
+
+// BulkAddNotesActivity posts the same note to many incidents concurrently.
+// Individual failures are reported in the output rather than failing the
+// activity, so a partial run can be inspected and retried for just the
+// failed incidents. If MaxFailures is exceeded, the remaining incidents are
+// skipped and ThresholdExceeded is set. The activity still succeeds in that
+// case, so the partial output reaches the caller and a retry does not post
+// the note again to incidents that already have it.
+func BulkAddNotesActivity(ctx context.Context, input BulkAddNotesInput) (BulkAddNotesOutput, error) {
+	if input.Message == "" {
+		return BulkAddNotesOutput{}, errors.New("message is required")
+	}
+	if input.From == "" {
+		return BulkAddNotesOutput{}, errors.New("from is required")
+	}
+
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey: input.APIKey,
+		From:   input.From,
+	})
+	if err != nil {
+		return BulkAddNotesOutput{}, err
+	}
+
+	budget := &failureBudget{max: input.MaxFailures}
+	errs := make([]error, len(input.IncidentIDs))
+	skipped := make([]bool, len(input.IncidentIDs))
+	runBounded(len(input.IncidentIDs), input.Concurrency, func(i int) {
+		if budget.exceeded() {
+			skipped[i] = true
+			return
+		}
+		if _, errs[i] = client.CreateNote(ctx, input.IncidentIDs[i], input.Message); errs[i] != nil {
+			budget.fail()
+		}
+	})
+
+	var output BulkAddNotesOutput
+	for i, id := range input.IncidentIDs {
+		if skipped[i] {
+			output.Skipped = append(output.Skipped, id)
+			continue
+		}
+		if errs[i] != nil {
+			output.Failed = append(output.Failed, IncidentFailure{
+				IncidentID: id,
+				Error:      errs[i].Error(),
+			})
+			continue
+		}
+		output.Succeeded = append(output.Succeeded, id)
+	}
+
+	output.ThresholdExceeded = budget.exceeded()
+
+	return output, nil
+}
+
+// BulkAddNotes creates a node for adding a note to many PagerDuty incidents.
+func BulkAddNotes(input BulkAddNotesInput) *core.Node[BulkAddNotesInput, BulkAddNotesOutput] {
+	return core.NewNode("pagerduty.BulkAddNotes", BulkAddNotesActivity, input)
+}
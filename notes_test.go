@@ -0,0 +1,102 @@
+package pagerduty
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// noteServer accepts notes, failing those posted to incidents in fail.
+func noteServer(t *testing.T, fail map[string]bool) (http.Handler, func() []string) {
+	var (
+		mu     sync.Mutex
+		posted []string
+	)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/incidents/"), "/notes")
+
+		mu.Lock()
+		posted = append(posted, id)
+		mu.Unlock()
+
+		if fail[id] {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeJSON(t, w, map[string]Note{"note": {ID: "N-" + id}})
+	})
+
+	return handler, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), posted...)
+	}
+}
+
+func TestBulkAddNotesActivity_StopsPastFailureThreshold(t *testing.T) {
+	// given
+	handler, posted := noteServer(t, map[string]bool{"P1": true, "P2": true})
+	useTestAPI(t, handler)
+
+	input := BulkAddNotesInput{
+		From:        "oncall@example.com",
+		IncidentIDs: []string{"P1", "P2", "P3", "P4"},
+		Message:     "mitigated",
+		Concurrency: 1,
+		MaxFailures: 1,
+	}
+
+	// when
+	output, err := BulkAddNotesActivity(context.Background(), input)
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !output.ThresholdExceeded {
+		t.Error("expected ThresholdExceeded")
+	}
+	if len(output.Failed) != 2 || output.Failed[0].IncidentID != "P1" || output.Failed[1].IncidentID != "P2" {
+		t.Errorf("got failed %+v, want P1 and P2", output.Failed)
+	}
+	if want := []string{"P3", "P4"}; !reflect.DeepEqual(output.Skipped, want) {
+		t.Errorf("got skipped %v, want %v", output.Skipped, want)
+	}
+	if got := posted(); len(got) != 2 {
+		t.Errorf("posted notes to %v, want only the two failing incidents", got)
+	}
+}
+
+func TestBulkAddNotesActivity_WithinFailureThreshold(t *testing.T) {
+	// given
+	handler, _ := noteServer(t, map[string]bool{"P2": true})
+	useTestAPI(t, handler)
+
+	input := BulkAddNotesInput{
+		From:        "oncall@example.com",
+		IncidentIDs: []string{"P1", "P2", "P3"},
+		Message:     "mitigated",
+		MaxFailures: 1,
+	}
+
+	// when
+	output, err := BulkAddNotesActivity(context.Background(), input)
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.ThresholdExceeded {
+		t.Error("did not expect ThresholdExceeded")
+	}
+	if want := []string{"P1", "P3"}; !reflect.DeepEqual(output.Succeeded, want) {
+		t.Errorf("got succeeded %v, want %v", output.Succeeded, want)
+	}
+	if len(output.Failed) != 1 || output.Failed[0].IncidentID != "P2" {
+		t.Errorf("got failed %+v, want P2", output.Failed)
+	}
+}
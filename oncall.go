@@ -0,0 +1,290 @@
+package pagerduty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	transform "github.com/resolute-sh/resolute-transform"
+	"github.com/resolute-sh/resolute/core"
+)
+
+// Calendar granularities for FetchOnCallCalendarInput.
+const (
+	// CalendarGranularityShift lists each on-call shift once.
+	CalendarGranularityShift = "shift"
+	// CalendarGranularityDay lists who is on call for each calendar day,
+	// splitting shifts that span midnight.
+	CalendarGranularityDay = "day"
+)
+
+// FetchOnCallCalendarInput is the input for FetchOnCallCalendarActivity.
+type FetchOnCallCalendarInput struct {
+	APIKey      string
+	SecretRef   string
+	ScheduleIDs []string
+	Since       time.Time
+	Until       time.Time
+
+	// Granularity is CalendarGranularityShift (the default) or
+	// CalendarGranularityDay.
+	Granularity string
+}
+
+// OnCallShift is a period during which a user is on call for a schedule.
+type OnCallShift struct {
+	ScheduleID string
+	UserID     string
+	UserName   string
+	Start      time.Time
+	End        time.Time
+}
+
+// CoverageGap is a period during which nobody is on call for a schedule.
+type CoverageGap struct {
+	ScheduleID string
+	Start      time.Time
+	End        time.Time
+}
+
+// FetchOnCallCalendarOutput is the output of FetchOnCallCalendarActivity.
+type FetchOnCallCalendarOutput struct {
+	Ref    core.DataRef
+	Count  int
+	Shifts []OnCallShift
+	Gaps   []CoverageGap
+}
+
+// FetchOnCallCalendarActivity renders who is on call for each schedule over
+// a window and stores one calendar document per schedule. Shifts and
+// uncovered periods are also returned so callers can build other views,
+// such as an ICS export.
+func FetchOnCallCalendarActivity(ctx context.Context, input FetchOnCallCalendarInput) (FetchOnCallCalendarOutput, error) {
+	if !input.Until.After(input.Since) {
+		return FetchOnCallCalendarOutput{}, errors.New("until must be after since")
+	}
+
+	granularity := input.Granularity
+	switch granularity {
+	case "":
+		granularity = CalendarGranularityShift
+	case CalendarGranularityShift, CalendarGranularityDay:
+	default:
+		return FetchOnCallCalendarOutput{}, fmt.Errorf("unknown granularity %q", granularity)
+	}
+
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey: input.APIKey,
+	})
+	if err != nil {
+		return FetchOnCallCalendarOutput{}, err
+	}
+
+	var output FetchOnCallCalendarOutput
+	docs := make([]transform.Document, 0, len(input.ScheduleIDs))
+	for _, scheduleID := range input.ScheduleIDs {
+		schedule, err := client.GetSchedule(ctx, scheduleID, input.Since, input.Until)
+		if err != nil {
+			return FetchOnCallCalendarOutput{}, fmt.Errorf("get schedule %s: %w", scheduleID, err)
+		}
+
+		shifts := scheduleShifts(*schedule, input.Since, input.Until)
+		gaps := coverageGaps(schedule.ID, shifts, input.Since, input.Until)
+
+		docs = append(docs, calendarToDocument(*schedule, shifts, gaps, input.Since, input.Until, granularity))
+		output.Shifts = append(output.Shifts, shifts...)
+		output.Gaps = append(output.Gaps, gaps...)
+	}
+
+	if err := postProcessDocuments(ctx, docs); err != nil {
+		return FetchOnCallCalendarOutput{}, err
+	}
+
+	ref, err := transform.StoreDocuments(ctx, docs)
+	if err != nil {
+		return FetchOnCallCalendarOutput{}, fmt.Errorf("store documents: %w", err)
+	}
+
+	output.Ref = ref
+	output.Count = len(docs)
+
+	return output, nil
+}
+
+// scheduleShifts returns a schedule's rendered entries clipped to the window
+// and ordered by start time.
+func scheduleShifts(schedule Schedule, since, until time.Time) []OnCallShift {
+	var shifts []OnCallShift
+	for _, entry := range schedule.FinalSchedule.RenderedScheduleEntries {
+		start, end := entry.Start, entry.End
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(until) {
+			end = until
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		shifts = append(shifts, OnCallShift{
+			ScheduleID: schedule.ID,
+			UserID:     entry.User.ID,
+			UserName:   entry.User.Summary,
+			Start:      start,
+			End:        end,
+		})
+	}
+
+	sort.SliceStable(shifts, func(i, j int) bool {
+		return shifts[i].Start.Before(shifts[j].Start)
+	})
+
+	return shifts
+}
+
+// coverageGaps returns the parts of the window not covered by any shift.
+// Shifts must be ordered by start time; overlapping shifts are treated as
+// continuous coverage.
+func coverageGaps(scheduleID string, shifts []OnCallShift, since, until time.Time) []CoverageGap {
+	var gaps []CoverageGap
+	covered := since
+	for _, shift := range shifts {
+		if shift.Start.After(covered) {
+			gaps = append(gaps, CoverageGap{ScheduleID: scheduleID, Start: covered, End: shift.Start})
+		}
+		if shift.End.After(covered) {
+			covered = shift.End
+		}
+	}
+	if until.After(covered) {
+		gaps = append(gaps, CoverageGap{ScheduleID: scheduleID, Start: covered, End: until})
+	}
+	return gaps
+}
+
+// calendarEntry is a line in a rendered calendar: a shift, or a gap when
+// name is empty.
+type calendarEntry struct {
+	start    time.Time
+	end      time.Time
+	name     string
+	overlaps bool
+}
+
+func calendarToDocument(schedule Schedule, shifts []OnCallShift, gaps []CoverageGap, since, until time.Time, granularity string) transform.Document {
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	var entries []calendarEntry
+	var coveredUntil time.Time
+	for _, shift := range shifts {
+		entries = append(entries, calendarEntry{
+			start:    shift.Start,
+			end:      shift.End,
+			name:     shift.UserName,
+			overlaps: shift.Start.Before(coveredUntil),
+		})
+		if shift.End.After(coveredUntil) {
+			coveredUntil = shift.End
+		}
+	}
+	for _, gap := range gaps {
+		entries = append(entries, calendarEntry{start: gap.Start, end: gap.End})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].start.Before(entries[j].start)
+	})
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("On-call calendar for %s from %s to %s (%s)",
+		schedule.Name, since.In(loc).Format(time.RFC3339), until.In(loc).Format(time.RFC3339), loc))
+
+	if granularity == CalendarGranularityDay {
+		lines = append(lines, dailyCalendarLines(entries, since, until, loc)...)
+	} else {
+		for _, entry := range entries {
+			lines = append(lines, formatCalendarEntry(entry, "2006-01-02 15:04", loc))
+		}
+	}
+
+	var uncovered time.Duration
+	for _, gap := range gaps {
+		uncovered += gap.End.Sub(gap.Start)
+	}
+	coverage := 100 * (1 - uncovered.Seconds()/until.Sub(since).Seconds())
+
+	metadata := map[string]string{
+		"document_type":    "oncall_calendar",
+		"schedule_id":      schedule.ID,
+		"schedule_name":    schedule.Name,
+		"window_start":     since.Format(time.RFC3339),
+		"window_end":       until.Format(time.RFC3339),
+		"granularity":      granularity,
+		"shift_count":      strconv.Itoa(len(shifts)),
+		"gap_count":        strconv.Itoa(len(gaps)),
+		"coverage_percent": strconv.FormatFloat(coverage, 'f', 1, 64),
+	}
+
+	return transform.Document{
+		ID:        fmt.Sprintf("oncall-calendar-%s-%d-%d", schedule.ID, since.Unix(), until.Unix()),
+		Content:   strings.Join(lines, "\n"),
+		Title:     "On-call calendar: " + schedule.Name,
+		Source:    "pagerduty",
+		URL:       schedule.HTMLURL,
+		Metadata:  metadata,
+		UpdatedAt: until,
+	}
+}
+
+// dailyCalendarLines groups entries under a heading per day, splitting
+// entries that cross midnight in loc.
+func dailyCalendarLines(entries []calendarEntry, since, until time.Time, loc *time.Location) []string {
+	var lines []string
+
+	s := since.In(loc)
+	day := time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, loc)
+	for day.Before(until) {
+		next := day.AddDate(0, 0, 1)
+		lines = append(lines, "", day.Format("Monday 2006-01-02"))
+
+		for _, entry := range entries {
+			if !entry.start.Before(next) || !entry.end.After(day) {
+				continue
+			}
+			if entry.start.Before(day) {
+				entry.start = day
+			}
+			if entry.end.After(next) {
+				entry.end = next
+			}
+			lines = append(lines, formatCalendarEntry(entry, "15:04", loc))
+		}
+
+		day = next
+	}
+
+	return lines
+}
+
+func formatCalendarEntry(entry calendarEntry, layout string, loc *time.Location) string {
+	span := fmt.Sprintf("%s - %s", entry.start.In(loc).Format(layout), entry.end.In(loc).Format(layout))
+	if entry.name == "" {
+		return span + ": UNCOVERED"
+	}
+	if entry.overlaps {
+		return fmt.Sprintf("%s: %s (overlapping)", span, entry.name)
+	}
+	return fmt.Sprintf("%s: %s", span, entry.name)
+}
+
+// FetchOnCallCalendar creates a node for rendering PagerDuty on-call calendars.
+func FetchOnCallCalendar(input FetchOnCallCalendarInput) *core.Node[FetchOnCallCalendarInput, FetchOnCallCalendarOutput] {
+	return core.NewNode("pagerduty.FetchOnCallCalendar", FetchOnCallCalendarActivity, input)
+}
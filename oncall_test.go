@@ -0,0 +1,97 @@
+package pagerduty
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCoverageGaps(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour int) time.Time { return since.Add(time.Duration(hour) * time.Hour) }
+	until := at(24)
+	shift := func(start, end int) OnCallShift {
+		return OnCallShift{ScheduleID: "PSCHED", Start: at(start), End: at(end)}
+	}
+	gap := func(start, end int) CoverageGap {
+		return CoverageGap{ScheduleID: "PSCHED", Start: at(start), End: at(end)}
+	}
+
+	tests := []struct {
+		name   string
+		shifts []OnCallShift
+		want   []CoverageGap
+	}{
+		{
+			name:   "fully covered",
+			shifts: []OnCallShift{shift(0, 12), shift(12, 24)},
+		},
+		{
+			name:   "leading gap",
+			shifts: []OnCallShift{shift(6, 24)},
+			want:   []CoverageGap{gap(0, 6)},
+		},
+		{
+			name:   "middle gap",
+			shifts: []OnCallShift{shift(0, 8), shift(10, 24)},
+			want:   []CoverageGap{gap(8, 10)},
+		},
+		{
+			name:   "trailing gap",
+			shifts: []OnCallShift{shift(0, 20)},
+			want:   []CoverageGap{gap(20, 24)},
+		},
+		{
+			name:   "overlapping shifts are continuous coverage",
+			shifts: []OnCallShift{shift(0, 10), shift(4, 8), shift(9, 24)},
+		},
+		{
+			name: "no shifts",
+			want: []CoverageGap{gap(0, 24)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// when
+			gaps := coverageGaps("PSCHED", tt.shifts, since, until)
+
+			// then
+			if !slices.Equal(gaps, tt.want) {
+				t.Errorf("got gaps %v, want %v", gaps, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyCalendarLines_SplitsShiftAcrossMidnight(t *testing.T) {
+	t.Parallel()
+
+	// given
+	loc := time.FixedZone("EST", -5*60*60)
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+	until := since.AddDate(0, 0, 2)
+	entries := []calendarEntry{{
+		start: time.Date(2024, 1, 1, 22, 0, 0, 0, loc).UTC(),
+		end:   time.Date(2024, 1, 2, 6, 0, 0, 0, loc).UTC(),
+		name:  "Alice",
+	}}
+
+	// when
+	lines := dailyCalendarLines(entries, since, until, loc)
+
+	// then
+	want := []string{
+		"", "Monday 2024-01-01",
+		"22:00 - 00:00: Alice",
+		"", "Tuesday 2024-01-02",
+		"00:00 - 06:00: Alice",
+	}
+	if !slices.Equal(lines, want) {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+}
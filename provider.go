@@ -1,7 +1,13 @@
-// Package pagerduty provides PagerDuty integration activities for resolute workflows.
 package pagerduty
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	transform "github.com/resolute-sh/resolute-transform"
 	"github.com/resolute-sh/resolute/core"
 	"go.temporal.io/sdk/worker"
 )
@@ -16,10 +22,189 @@ func Provider() core.Provider {
 	return core.NewProvider(ProviderName, ProviderVersion).
 		AddActivity("pagerduty.FetchIncidents", FetchIncidentsActivity).
 		AddActivity("pagerduty.FetchIncident", FetchIncidentActivity).
-		AddActivity("pagerduty.FetchPostmortems", FetchPostmortemsActivity)
+		AddActivity("pagerduty.FetchPostmortems", FetchPostmortemsActivity).
+		AddActivity("pagerduty.FetchServiceIncidentCounts", FetchServiceIncidentCountsActivity).
+		AddActivity("pagerduty.BulkAddNotes", BulkAddNotesActivity).
+		AddActivity("pagerduty.FetchOnCallCalendar", FetchOnCallCalendarActivity).
+		AddActivity("pagerduty.ResolveIncidentsByFilter", ResolveIncidentsByFilterActivity).
+		AddActivity("pagerduty.BackfillIncidents", BackfillIncidentsActivity).
+		AddActivity("pagerduty.RenderIncidentSummary", RenderIncidentSummaryActivity).
+		AddActivity("pagerduty.FindPendingResponders", FindPendingRespondersActivity).
+		AddActivity("pagerduty.FetchTeamIncidents", FetchTeamIncidentsActivity)
 }
 
 // RegisterActivities registers all PagerDuty activities with a Temporal worker.
 func RegisterActivities(w worker.Worker) {
 	core.RegisterProviderActivities(w, Provider())
 }
+
+var (
+	activityConfigMu    sync.RWMutex
+	activityRateLimiter core.RateLimiter
+	activityDocumentKey func(Incident) string
+	activitySecrets     SecretResolver
+	activityStatus      StatusNormalizer
+	activityLogger      *slog.Logger
+	activityPostProcess PostProcessor
+	activityBaseURL     string
+)
+
+// SecretResolver resolves a secret reference, such as a secrets manager
+// path, to its value.
+type SecretResolver func(ctx context.Context, ref string) (string, error)
+
+// SetSecretResolver sets the resolver used to look up the API key for
+// activity inputs that set SecretRef instead of APIKey. The key is resolved
+// when the activity runs, so it never appears in workflow history. Inputs
+// without a SecretRef, or run while no resolver is set, use APIKey. Pass
+// nil to remove it.
+func SetSecretResolver(resolver SecretResolver) {
+	activityConfigMu.Lock()
+	defer activityConfigMu.Unlock()
+	activitySecrets = resolver
+}
+
+// SetRateLimiter sets a rate limiter shared by every API request made by
+// PagerDuty activities in this worker. Pass nil to remove it.
+func SetRateLimiter(limiter core.RateLimiter) {
+	activityConfigMu.Lock()
+	defer activityConfigMu.Unlock()
+	activityRateLimiter = limiter
+}
+
+// SetBaseURL sets the REST API endpoint used by PagerDuty activities in this
+// worker, e.g. https://api.eu.pagerduty.com for accounts in the EU service
+// region. Pass "" to restore the default, https://api.pagerduty.com.
+func SetBaseURL(endpoint string) {
+	activityConfigMu.Lock()
+	defer activityConfigMu.Unlock()
+	activityBaseURL = endpoint
+}
+
+// SetLogger sets the logger used by clients created by PagerDuty activities
+// in this worker, e.g. to report retried requests. Pass nil to disable
+// logging.
+func SetLogger(logger *slog.Logger) {
+	activityConfigMu.Lock()
+	defer activityConfigMu.Unlock()
+	activityLogger = logger
+}
+
+// SetDocumentKey sets the function that derives a document's ID, and so
+// its upsert key in the downstream store, from an incident. Use it when the
+// store keys documents differently, for example by service and incident
+// rather than incident alone. Pass nil to restore the default, the incident
+// ID.
+func SetDocumentKey(fn func(Incident) string) {
+	activityConfigMu.Lock()
+	defer activityConfigMu.Unlock()
+	activityDocumentKey = fn
+}
+
+// documentKey returns the document ID for an incident.
+func documentKey(incident Incident) string {
+	activityConfigMu.RLock()
+	defer activityConfigMu.RUnlock()
+
+	if activityDocumentKey == nil {
+		return incident.ID
+	}
+	return activityDocumentKey(incident)
+}
+
+// StatusNormalizer maps a PagerDuty incident status to the status recorded
+// in document metadata.
+type StatusNormalizer func(status string) string
+
+// SetStatusNormalizer sets the normalizer applied to the status metadata of
+// incident documents, e.g. OpenClosedStatus for consumers with a binary
+// status model. While one is set the original status is kept as
+// raw_status. Pass nil to record statuses unchanged.
+func SetStatusNormalizer(normalizer StatusNormalizer) {
+	activityConfigMu.Lock()
+	defer activityConfigMu.Unlock()
+	activityStatus = normalizer
+}
+
+// statusNormalizer returns the normalizer set with SetStatusNormalizer, or
+// nil.
+func statusNormalizer() StatusNormalizer {
+	activityConfigMu.RLock()
+	defer activityConfigMu.RUnlock()
+	return activityStatus
+}
+
+// PostProcessor transforms an incident document after it is built and
+// before it is stored, e.g. to redact PII or add fields from another
+// system.
+type PostProcessor func(ctx context.Context, doc transform.Document) (transform.Document, error)
+
+// SetPostProcessor sets the post-processor applied to every document
+// stored by PagerDuty activities in this worker, including summary
+// documents. An error fails the activity. Pass nil to store documents as
+// built.
+func SetPostProcessor(fn PostProcessor) {
+	activityConfigMu.Lock()
+	defer activityConfigMu.Unlock()
+	activityPostProcess = fn
+}
+
+// postProcessDocuments applies the post-processor, if any, to docs in
+// place, stopping at the first error.
+func postProcessDocuments(ctx context.Context, docs []transform.Document) error {
+	activityConfigMu.RLock()
+	fn := activityPostProcess
+	activityConfigMu.RUnlock()
+
+	if fn == nil {
+		return nil
+	}
+
+	for i := range docs {
+		doc, err := fn(ctx, docs[i])
+		if err != nil {
+			return fmt.Errorf("post-process document %s: %w", docs[i].ID, err)
+		}
+		docs[i] = doc
+	}
+	return nil
+}
+
+// activityClient creates the client used by an activity, applying
+// worker-level settings that cannot travel in activity inputs. A non-empty
+// secretRef is resolved to the client's API key; without a resolver the
+// APIKey in cfg is used instead, if there is one.
+func activityClient(ctx context.Context, secretRef string, cfg ClientConfig) (*Client, error) {
+	activityConfigMu.RLock()
+	limiter, resolver, logger := activityRateLimiter, activitySecrets, activityLogger
+	endpoint := activityBaseURL
+	activityConfigMu.RUnlock()
+
+	switch {
+	case secretRef == "":
+	case resolver != nil:
+		key, err := resolver(ctx, secretRef)
+		if err != nil {
+			return nil, fmt.Errorf("resolve API key: %w", err)
+		}
+		cfg.APIKey = key
+	case cfg.APIKey == "":
+		return nil, errors.New("SecretRef is set but no SecretResolver is configured")
+	}
+
+	if cfg.RateLimiter == nil {
+		cfg.RateLimiter = limiter
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logger
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = endpoint
+	}
+
+	client := NewClient(cfg)
+	if client.configErr != nil {
+		return nil, client.configErr
+	}
+	return client, nil
+}
@@ -0,0 +1,267 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	transform "github.com/resolute-sh/resolute-transform"
+)
+
+// usePostProcessor sets the worker's post-processor for the rest of the test.
+func usePostProcessor(t *testing.T, fn PostProcessor) {
+	t.Helper()
+
+	SetPostProcessor(fn)
+	t.Cleanup(func() { SetPostProcessor(nil) })
+}
+
+func TestFetchIncidentsActivity_PostProcessesSummaryDocument(t *testing.T) {
+	// given
+	const email = "alice@example.com"
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, IncidentListResponse{Incidents: []Incident{{
+			ID:        "P1",
+			Summary:   "login failing for " + email,
+			Status:    StatusTriggered,
+			CreatedAt: created,
+			Service:   Service{Name: "owned by " + email},
+		}}})
+	}))
+	usePostProcessor(t, func(ctx context.Context, doc transform.Document) (transform.Document, error) {
+		doc.Content = strings.ReplaceAll(doc.Content, email, "[redacted]")
+		for key, value := range doc.Metadata {
+			doc.Metadata[key] = strings.ReplaceAll(value, email, "[redacted]")
+		}
+		return doc, nil
+	})
+
+	// when
+	output, err := FetchIncidentsActivity(context.Background(), FetchIncidentsInput{
+		EmitSummary: true,
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	docs := append(loadDocs(t, output.Ref), loadDocs(t, output.SummaryRef)...)
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want the incident and its summary", len(docs))
+	}
+	for _, doc := range docs {
+		if strings.Contains(doc.Content, email) {
+			t.Errorf("document %s content was not redacted: %q", doc.ID, doc.Content)
+		}
+		for key, value := range doc.Metadata {
+			if strings.Contains(value, email) {
+				t.Errorf("document %s metadata %s was not redacted: %q", doc.ID, key, value)
+			}
+		}
+	}
+}
+
+func TestFetchOnCallCalendarActivity_PostProcessesCalendarDocument(t *testing.T) {
+	// given
+	const name = "Alice Example"
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+	useTestAPI(t, apiRoutes(t, map[string]interface{}{
+		"/schedules/S1": map[string]interface{}{"schedule": Schedule{
+			ID:   "S1",
+			Name: "Primary",
+			FinalSchedule: ScheduleLayer{RenderedScheduleEntries: []ScheduleEntry{{
+				Start: since,
+				End:   until,
+				User:  Agent{ID: "U1", Type: "user_reference", Summary: name},
+			}}},
+		}},
+	}))
+	usePostProcessor(t, func(ctx context.Context, doc transform.Document) (transform.Document, error) {
+		doc.Content = strings.ReplaceAll(doc.Content, name, "[redacted]")
+		return doc, nil
+	})
+
+	// when
+	output, err := FetchOnCallCalendarActivity(context.Background(), FetchOnCallCalendarInput{
+		ScheduleIDs: []string{"S1"},
+		Since:       since,
+		Until:       until,
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	docs := loadDocs(t, output.Ref)
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	if strings.Contains(docs[0].Content, name) || !strings.Contains(docs[0].Content, "[redacted]") {
+		t.Errorf("calendar content was not redacted: %q", docs[0].Content)
+	}
+}
+
+func TestFetchIncidentsActivity_CustomDocumentKey(t *testing.T) {
+	// given
+	useTestAPI(t, listIncidents(t, Incident{ID: "P1", IncidentNumber: 42, Service: Service{ID: "PS1"}}))
+	SetDocumentKey(func(incident Incident) string {
+		return fmt.Sprintf("%s:%d", incident.Service.ID, incident.IncidentNumber)
+	})
+	t.Cleanup(func() { SetDocumentKey(nil) })
+
+	// when
+	_, docs := fetchIncidentDocs(t, FetchIncidentsInput{})
+
+	// then
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	if docs[0].ID != "PS1:42" {
+		t.Errorf("got document ID %q, want PS1:42", docs[0].ID)
+	}
+	if got := docs[0].Metadata["incident_id"]; got != "P1" {
+		t.Errorf("got incident_id %q, want P1", got)
+	}
+}
+
+func TestFetchIncidentsActivity_DefaultDocumentKey(t *testing.T) {
+	// given
+	useTestAPI(t, listIncidents(t, Incident{ID: "P1", IncidentNumber: 42}))
+
+	// when
+	_, docs := fetchIncidentDocs(t, FetchIncidentsInput{})
+
+	// then
+	if len(docs) != 1 || docs[0].ID != "P1" {
+		t.Fatalf("got documents %v, want one with ID P1", docs)
+	}
+}
+
+func TestFetchIncidentsActivity_APIKeyResolution(t *testing.T) {
+	resolver := func(ctx context.Context, ref string) (string, error) {
+		if ref != "vault://pagerduty/api-key" {
+			return "", fmt.Errorf("unknown secret %q", ref)
+		}
+		return "resolved-key", nil
+	}
+
+	tests := []struct {
+		name      string
+		resolver  SecretResolver
+		input     FetchIncidentsInput
+		wantToken string
+		wantErr   bool
+	}{
+		{
+			name:      "secret resolved",
+			resolver:  resolver,
+			input:     FetchIncidentsInput{SecretRef: "vault://pagerduty/api-key", APIKey: "plain-key"},
+			wantToken: "Token token=resolved-key",
+		},
+		{
+			name:      "no secret reference",
+			resolver:  resolver,
+			input:     FetchIncidentsInput{APIKey: "plain-key"},
+			wantToken: "Token token=plain-key",
+		},
+		{
+			name:      "no resolver falls back to APIKey",
+			input:     FetchIncidentsInput{SecretRef: "vault://pagerduty/api-key", APIKey: "plain-key"},
+			wantToken: "Token token=plain-key",
+		},
+		{
+			name:    "no resolver and no APIKey",
+			input:   FetchIncidentsInput{SecretRef: "vault://pagerduty/api-key"},
+			wantErr: true,
+		},
+		{
+			name:     "resolver fails",
+			resolver: resolver,
+			input:    FetchIncidentsInput{SecretRef: "vault://other"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			var token string
+			useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token = r.Header.Get("Authorization")
+				writeJSON(t, w, IncidentListResponse{})
+			}))
+			SetSecretResolver(tt.resolver)
+			t.Cleanup(func() { SetSecretResolver(nil) })
+
+			// when
+			_, err := FetchIncidentsActivity(context.Background(), tt.input)
+
+			// then
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if token != "" {
+					t.Errorf("sent a request with %q, want none", token)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token != tt.wantToken {
+				t.Errorf("got Authorization %q, want %q", token, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestIncidentToDocument_OpenClosedStatus(t *testing.T) {
+	// given
+	SetStatusNormalizer(OpenClosedStatus)
+	t.Cleanup(func() { SetStatusNormalizer(nil) })
+
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{status: StatusTriggered, want: StatusOpen},
+		{status: StatusAcknowledged, want: StatusOpen},
+		{status: StatusResolved, want: StatusClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			// when
+			doc := incidentToDocument(Incident{ID: "P1", Status: tt.status})
+
+			// then
+			if got := doc.Metadata["status"]; got != tt.want {
+				t.Errorf("got status %q, want %q", got, tt.want)
+			}
+			if got := doc.Metadata["raw_status"]; got != tt.status {
+				t.Errorf("got raw_status %q, want %q", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestIncidentToDocument_StatusUnchangedWithoutNormalizer(t *testing.T) {
+	// when
+	doc := incidentToDocument(Incident{ID: "P1", Status: StatusAcknowledged})
+
+	// then
+	if got := doc.Metadata["status"]; got != StatusAcknowledged {
+		t.Errorf("got status %q, want %q", got, StatusAcknowledged)
+	}
+	if _, ok := doc.Metadata["raw_status"]; ok {
+		t.Error("got raw_status, want none without a normalizer")
+	}
+}
@@ -0,0 +1,103 @@
+package pagerduty
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewPriorityRateLimiter_RejectsNonPositiveRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		requests int
+		per      time.Duration
+	}{
+		{name: "zero requests", requests: 0, per: time.Second},
+		{name: "negative requests", requests: -1, per: time.Second},
+		{name: "zero period", requests: 10, per: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			NewPriorityRateLimiter(tt.requests, tt.per)
+		})
+	}
+}
+
+func TestNewPriorityRateLimiter_ClampsPollInterval(t *testing.T) {
+	t.Parallel()
+
+	// given / when
+	limiter := NewPriorityRateLimiter(1_000_000, time.Millisecond)
+
+	// then
+	if limiter.interval < minPollInterval {
+		t.Errorf("got poll interval %s, want at least %s", limiter.interval, minPollInterval)
+	}
+}
+
+func TestPriorityRateLimiter_ServesHighestPriorityFirst(t *testing.T) {
+	t.Parallel()
+
+	// given - a limiter with its only token already taken
+	limiter := NewPriorityRateLimiter(1, 100*time.Millisecond)
+	if !limiter.TryAcquire() {
+		t.Fatal("expected to acquire the initial token")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	order := make(chan int, 3)
+	wait := func(priority int) {
+		if err := limiter.WaitPriority(ctx, priority); err != nil {
+			t.Errorf("wait at priority %d: %v", priority, err)
+			return
+		}
+		order <- priority
+	}
+
+	// when - waiters queue in increasing priority order
+	for i, priority := range []int{PriorityLow, PriorityNormal, PriorityHigh} {
+		go wait(priority)
+		waitForWaiters(t, limiter, i+1)
+	}
+
+	// then
+	for _, want := range []int{PriorityHigh, PriorityNormal, PriorityLow} {
+		select {
+		case got := <-order:
+			if got != want {
+				t.Fatalf("got priority %d, want %d", got, want)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for tokens")
+		}
+	}
+}
+
+// waitForWaiters blocks until n requests are queued on the limiter.
+func waitForWaiters(t *testing.T, limiter *PriorityRateLimiter, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		limiter.mu.Lock()
+		queued := len(limiter.waiters)
+		limiter.mu.Unlock()
+		if queued >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d queued requests", n)
+}
@@ -0,0 +1,50 @@
+package pagerduty
+
+import (
+	"regexp"
+	"sort"
+)
+
+var (
+	incidentURLPattern    = regexp.MustCompile(`(?i)[a-z0-9.-]*pagerduty\.com/(?:#/)?incidents/([a-z0-9]+)`)
+	incidentNumberPattern = regexp.MustCompile(`(?i)(?:^|[^\w&])(?:#|INC-)(\d+)\b`)
+)
+
+// ExtractIncidentReferences finds PagerDuty incidents mentioned in free
+// text, such as a chat message, and returns their identifiers in the order
+// they first appear, without duplicates.
+//
+// Web and API incident URLs (including /timeline and other sub-pages) yield
+// the incident ID. Incident numbers written as "#1234" or "INC-1234" yield
+// the number, which PagerDuty accepts in place of an ID, so every result
+// can be passed to GetIncident or FetchIncidentActivity.
+func ExtractIncidentReferences(text string) []string {
+	type match struct {
+		pos int
+		ref string
+	}
+
+	var matches []match
+	for _, m := range incidentURLPattern.FindAllStringSubmatchIndex(text, -1) {
+		matches = append(matches, match{pos: m[0], ref: text[m[2]:m[3]]})
+	}
+	for _, m := range incidentNumberPattern.FindAllStringSubmatchIndex(text, -1) {
+		matches = append(matches, match{pos: m[2], ref: text[m[2]:m[3]]})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].pos < matches[j].pos
+	})
+
+	seen := make(map[string]bool, len(matches))
+	refs := make([]string, 0, len(matches))
+	for _, m := range matches {
+		if seen[m.ref] {
+			continue
+		}
+		seen[m.ref] = true
+		refs = append(refs, m.ref)
+	}
+
+	return refs
+}
@@ -0,0 +1,76 @@
+package pagerduty
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractIncidentReferences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "web URL",
+			text: "see https://acme.pagerduty.com/incidents/PABC123 for details",
+			want: []string{"PABC123"},
+		},
+		{
+			name: "legacy web URL",
+			text: "https://acme.pagerduty.com/#/incidents/PABC123",
+			want: []string{"PABC123"},
+		},
+		{
+			name: "timeline URL",
+			text: "<https://acme.pagerduty.com/incidents/PABC123/timeline|timeline>",
+			want: []string{"PABC123"},
+		},
+		{
+			name: "API URL",
+			text: "GET https://api.pagerduty.com/incidents/PXYZ789",
+			want: []string{"PXYZ789"},
+		},
+		{
+			name: "hash number",
+			text: "is #1234 still open?",
+			want: []string{"1234"},
+		},
+		{
+			name: "INC number",
+			text: "INC-1234 and inc-99 look related",
+			want: []string{"1234", "99"},
+		},
+		{
+			name: "mixed references in order without duplicates",
+			text: "#42 again, like INC-7 and https://acme.pagerduty.com/incidents/PABC123; dup #42 and https://acme.pagerduty.com/incidents/PABC123/log_entries",
+			want: []string{"42", "7", "PABC123"},
+		},
+		{
+			name: "HTML entity",
+			text: "escaped &#123; is not an incident",
+			want: []string{},
+		},
+		{
+			name: "no references",
+			text: "all quiet",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// when
+			refs := ExtractIncidentReferences(tt.text)
+
+			// then
+			if !slices.Equal(refs, tt.want) {
+				t.Errorf("got %v, want %v", refs, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package pagerduty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// manageIncidentsBatchSize is the most updates PagerDuty accepts in one
+// ManageIncidents request.
+const manageIncidentsBatchSize = 250
+
+// ResolveIncidentsByFilterInput is the input for ResolveIncidentsByFilterActivity.
+type ResolveIncidentsByFilterInput struct {
+	APIKey    string
+	SecretRef string
+
+	// From is the email of the PagerDuty user the resolutions are
+	// attributed to.
+	From string
+
+	// At least one of ServiceIDs, Urgencies or OlderThan must be set, so an
+	// empty input can never resolve every open incident in the account.
+	ServiceIDs []string
+	Urgencies  []string
+	OlderThan  time.Duration
+
+	// Statuses narrows which open incidents are resolved. Defaults to
+	// triggered and acknowledged.
+	Statuses []string
+
+	// Resolution is an optional note recorded with each resolution.
+	Resolution string
+
+	// DryRun lists the matching incidents without resolving them.
+	DryRun bool
+}
+
+// ResolveIncidentsByFilterOutput is the output of ResolveIncidentsByFilterActivity.
+type ResolveIncidentsByFilterOutput struct {
+	// IncidentIDs lists every incident that matched the filter.
+	IncidentIDs []string
+	Matched     int
+	Resolved    int
+	Failed      []IncidentFailure
+}
+
+// ResolveIncidentsByFilterActivity resolves every open incident matching a
+// filter, e.g. all low-urgency incidents older than a week. Use DryRun to
+// preview which incidents would be resolved.
+func ResolveIncidentsByFilterActivity(ctx context.Context, input ResolveIncidentsByFilterInput) (ResolveIncidentsByFilterOutput, error) {
+	if len(input.ServiceIDs) == 0 && len(input.Urgencies) == 0 && input.OlderThan <= 0 {
+		return ResolveIncidentsByFilterOutput{}, errors.New("at least one of ServiceIDs, Urgencies or OlderThan is required")
+	}
+	if input.From == "" && !input.DryRun {
+		return ResolveIncidentsByFilterOutput{}, errors.New("from is required")
+	}
+
+	statuses := input.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{StatusTriggered, StatusAcknowledged}
+	}
+	for _, status := range statuses {
+		if status == StatusResolved {
+			return ResolveIncidentsByFilterOutput{}, errors.New("statuses must not include resolved")
+		}
+	}
+
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey: input.APIKey,
+		From:   input.From,
+	})
+	if err != nil {
+		return ResolveIncidentsByFilterOutput{}, err
+	}
+
+	var cutoff time.Time
+	if input.OlderThan > 0 {
+		cutoff = time.Now().Add(-input.OlderThan)
+	}
+
+	var output ResolveIncidentsByFilterOutput
+	for offset := 0; ; {
+		page, err := client.ListIncidentsWithOptions(ctx, ListIncidentsOptions{
+			Limit:      100,
+			Offset:     offset,
+			Statuses:   statuses,
+			Urgencies:  input.Urgencies,
+			ServiceIDs: input.ServiceIDs,
+			AllDates:   true,
+		})
+		if err != nil {
+			return ResolveIncidentsByFilterOutput{}, fmt.Errorf("list incidents: %w", err)
+		}
+
+		for _, incident := range page.Incidents {
+			if !cutoff.IsZero() && !incident.CreatedAt.Before(cutoff) {
+				continue
+			}
+			output.IncidentIDs = append(output.IncidentIDs, incident.ID)
+		}
+
+		if !page.More || len(page.Incidents) == 0 {
+			break
+		}
+		offset += len(page.Incidents)
+	}
+
+	output.Matched = len(output.IncidentIDs)
+	if input.DryRun {
+		return output, nil
+	}
+
+	for start := 0; start < len(output.IncidentIDs); start += manageIncidentsBatchSize {
+		end := start + manageIncidentsBatchSize
+		if end > len(output.IncidentIDs) {
+			end = len(output.IncidentIDs)
+		}
+		batch := output.IncidentIDs[start:end]
+
+		updates := make([]IncidentUpdate, 0, len(batch))
+		for _, id := range batch {
+			updates = append(updates, IncidentUpdate{
+				ID:         id,
+				Status:     StatusResolved,
+				Resolution: input.Resolution,
+			})
+		}
+
+		if _, err := client.ManageIncidents(ctx, updates); err != nil {
+			for _, id := range batch {
+				output.Failed = append(output.Failed, IncidentFailure{
+					IncidentID: id,
+					Error:      err.Error(),
+				})
+			}
+			continue
+		}
+		output.Resolved += len(batch)
+	}
+
+	return output, nil
+}
+
+// ResolveIncidentsByFilter creates a node for resolving PagerDuty incidents matching a filter.
+func ResolveIncidentsByFilter(input ResolveIncidentsByFilterInput) *core.Node[ResolveIncidentsByFilterInput, ResolveIncidentsByFilterOutput] {
+	return core.NewNode("pagerduty.ResolveIncidentsByFilter", ResolveIncidentsByFilterActivity, input)
+}
@@ -0,0 +1,218 @@
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// resolveAPI serves a paginated open incident list and records the
+// ManageIncidents batches it receives. PUTs whose batch number is listed in
+// failBatches are rejected.
+type resolveAPI struct {
+	t           *testing.T
+	incidents   []Incident
+	failBatches map[int]bool
+
+	mu      sync.Mutex
+	batches [][]IncidentUpdate
+}
+
+func (a *resolveAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		end := offset + limit
+		if end > len(a.incidents) {
+			end = len(a.incidents)
+		}
+		writeJSON(a.t, w, IncidentListResponse{
+			Incidents: a.incidents[offset:end],
+			Offset:    offset,
+			Limit:     limit,
+			More:      end < len(a.incidents),
+		})
+	case http.MethodPut:
+		var body struct {
+			Incidents []IncidentUpdate `json:"incidents"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			a.t.Errorf("decode request: %v", err)
+		}
+
+		a.mu.Lock()
+		a.batches = append(a.batches, body.Incidents)
+		batch := len(a.batches)
+		a.mu.Unlock()
+
+		if a.failBatches[batch] {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(a.t, w, map[string]interface{}{"error": map[string]string{"message": "Invalid Input Provided"}})
+			return
+		}
+		writeJSON(a.t, w, map[string]interface{}{"incidents": body.Incidents})
+	default:
+		a.t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+	}
+}
+
+// openIncidents returns n open incidents created at createdAt.
+func openIncidents(n int, createdAt time.Time) []Incident {
+	incidents := make([]Incident, n)
+	for i := range incidents {
+		incidents[i] = Incident{
+			ID:        fmt.Sprintf("P%d", i+1),
+			Status:    StatusTriggered,
+			CreatedAt: createdAt,
+		}
+	}
+	return incidents
+}
+
+func TestResolveIncidentsByFilterActivity_RejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   ResolveIncidentsByFilterInput
+		wantErr string
+	}{
+		{
+			name:    "empty filter",
+			input:   ResolveIncidentsByFilterInput{From: "oncall@example.com"},
+			wantErr: "at least one of ServiceIDs, Urgencies or OlderThan is required",
+		},
+		{
+			name: "missing from",
+			input: ResolveIncidentsByFilterInput{
+				Urgencies: []string{"low"},
+			},
+			wantErr: "from is required",
+		},
+		{
+			name: "resolved status",
+			input: ResolveIncidentsByFilterInput{
+				From:      "oncall@example.com",
+				Urgencies: []string{"low"},
+				Statuses:  []string{StatusTriggered, StatusResolved},
+			},
+			wantErr: "statuses must not include resolved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			api := &resolveAPI{t: t, incidents: openIncidents(1, time.Now())}
+			useTestAPI(t, api)
+
+			// when
+			_, err := ResolveIncidentsByFilterActivity(context.Background(), tt.input)
+
+			// then
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if len(api.batches) != 0 {
+				t.Errorf("got %d PUT requests, want none", len(api.batches))
+			}
+		})
+	}
+}
+
+func TestResolveIncidentsByFilterActivity_DryRunSendsNoUpdates(t *testing.T) {
+	// given
+	api := &resolveAPI{t: t, incidents: openIncidents(3, time.Now())}
+	useTestAPI(t, api)
+
+	// when
+	output, err := ResolveIncidentsByFilterActivity(context.Background(), ResolveIncidentsByFilterInput{
+		Urgencies: []string{"low"},
+		DryRun:    true,
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Matched != 3 || output.Resolved != 0 {
+		t.Errorf("got matched %d resolved %d, want 3 and 0", output.Matched, output.Resolved)
+	}
+	if len(api.batches) != 0 {
+		t.Errorf("got %d PUT requests, want none", len(api.batches))
+	}
+}
+
+func TestResolveIncidentsByFilterActivity_OlderThanSkipsRecentIncidents(t *testing.T) {
+	// given
+	now := time.Now()
+	api := &resolveAPI{t: t, incidents: []Incident{
+		{ID: "P1", Status: StatusTriggered, CreatedAt: now.Add(-8 * 24 * time.Hour)},
+		{ID: "P2", Status: StatusTriggered, CreatedAt: now.Add(-time.Hour)},
+		{ID: "P3", Status: StatusAcknowledged, CreatedAt: now.Add(-30 * 24 * time.Hour)},
+	}}
+	useTestAPI(t, api)
+
+	// when
+	output, err := ResolveIncidentsByFilterActivity(context.Background(), ResolveIncidentsByFilterInput{
+		From:      "oncall@example.com",
+		OlderThan: 7 * 24 * time.Hour,
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(output.IncidentIDs, ","); got != "P1,P3" {
+		t.Errorf("got incident IDs %q, want P1,P3", got)
+	}
+	if output.Resolved != 2 {
+		t.Errorf("got %d resolved, want 2", output.Resolved)
+	}
+}
+
+func TestResolveIncidentsByFilterActivity_BatchesUpdates(t *testing.T) {
+	// given
+	api := &resolveAPI{
+		t:           t,
+		incidents:   openIncidents(600, time.Now()),
+		failBatches: map[int]bool{2: true},
+	}
+	useTestAPI(t, api)
+
+	// when
+	output, err := ResolveIncidentsByFilterActivity(context.Background(), ResolveIncidentsByFilterInput{
+		From:       "oncall@example.com",
+		Urgencies:  []string{"low"},
+		Resolution: "stale",
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sizes []int
+	for _, batch := range api.batches {
+		sizes = append(sizes, len(batch))
+	}
+	if fmt.Sprint(sizes) != "[250 250 100]" {
+		t.Errorf("got batch sizes %v, want [250 250 100]", sizes)
+	}
+	if update := api.batches[0][0]; update.Status != StatusResolved || update.Resolution != "stale" {
+		t.Errorf("got update %+v, want a resolution with note stale", update)
+	}
+	if output.Matched != 600 || output.Resolved != 350 {
+		t.Errorf("got matched %d resolved %d, want 600 and 350", output.Matched, output.Resolved)
+	}
+	if len(output.Failed) != 250 {
+		t.Fatalf("got %d failures, want 250", len(output.Failed))
+	}
+	if failure := output.Failed[0]; failure.IncidentID != "P251" || !strings.Contains(failure.Error, "Invalid Input Provided") {
+		t.Errorf("got first failure %+v, want P251 with the API error", failure)
+	}
+}
@@ -0,0 +1,118 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// FindPendingRespondersInput is the input for FindPendingRespondersActivity.
+type FindPendingRespondersInput struct {
+	APIKey     string
+	SecretRef  string
+	ServiceIDs []string
+
+	// Limit bounds how many open incidents are checked, and so how many
+	// incident lookups are made. Defaults to 100.
+	Limit int
+
+	// Concurrency bounds how many incidents are looked up at once.
+	// Defaults to 4.
+	Concurrency int
+}
+
+// PendingResponders is an incident with responder requests that have not
+// been answered.
+type PendingResponders struct {
+	IncidentID string
+	Summary    string
+	HTMLURL    string
+	Responders []Responder
+}
+
+// FindPendingRespondersOutput is the output of FindPendingRespondersActivity.
+type FindPendingRespondersOutput struct {
+	Incidents []PendingResponders
+
+	// Checked is the number of open incidents inspected.
+	Checked int
+}
+
+// FindPendingRespondersActivity finds open incidents where responders were
+// asked to help but have not yet accepted, so a workflow can re-page them
+// or escalate. Each incident is looked up individually to read its
+// responder requests.
+func FindPendingRespondersActivity(ctx context.Context, input FindPendingRespondersInput) (FindPendingRespondersOutput, error) {
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey: input.APIKey,
+	})
+	if err != nil {
+		return FindPendingRespondersOutput{}, err
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	result, err := client.ListIncidentsWithOptions(ctx, ListIncidentsOptions{
+		Limit:      limit,
+		Statuses:   []string{StatusTriggered, StatusAcknowledged},
+		ServiceIDs: input.ServiceIDs,
+	})
+	if err != nil {
+		return FindPendingRespondersOutput{}, fmt.Errorf("list incidents: %w", err)
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	pending := make([][]Responder, len(result.Incidents))
+	runBounded(len(result.Incidents), input.Concurrency, func(i int) {
+		id := result.Incidents[i].ID
+		incident, err := client.GetIncident(ctx, id)
+		if err != nil {
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("get incident %s: %w", id, err)
+			}
+			mu.Unlock()
+			return
+		}
+
+		for _, responder := range incident.Responders {
+			if responder.State == ResponderStatePending {
+				pending[i] = append(pending[i], responder)
+			}
+		}
+	})
+
+	if firstErr != nil {
+		return FindPendingRespondersOutput{}, firstErr
+	}
+
+	output := FindPendingRespondersOutput{Checked: len(result.Incidents)}
+	for i, incident := range result.Incidents {
+		if len(pending[i]) == 0 {
+			continue
+		}
+		output.Incidents = append(output.Incidents, PendingResponders{
+			IncidentID: incident.ID,
+			Summary:    incident.Summary,
+			HTMLURL:    incident.HTMLURL,
+			Responders: pending[i],
+		})
+	}
+
+	return output, nil
+}
+
+// FindPendingResponders creates a node for finding incidents with
+// unanswered responder requests.
+func FindPendingResponders(input FindPendingRespondersInput) *core.Node[FindPendingRespondersInput, FindPendingRespondersOutput] {
+	return core.NewNode("pagerduty.FindPendingResponders", FindPendingRespondersActivity, input)
+}
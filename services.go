@@ -0,0 +1,206 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	transform "github.com/resolute-sh/resolute-transform"
+	"github.com/resolute-sh/resolute/core"
+)
+
+const defaultCountWindow = 30 * 24 * time.Hour
+
+// FetchServiceIncidentCountsInput is the input for FetchServiceIncidentCountsActivity.
+type FetchServiceIncidentCountsInput struct {
+	APIKey    string
+	SecretRef string
+	TeamIDs   []string
+
+	// Since and Until bound the counting window. Until defaults to now and
+	// Since defaults to Until minus Window.
+	Since  *time.Time
+	Until  *time.Time
+	Window time.Duration
+
+	// Concurrency bounds how many services are counted at once. Defaults
+	// to 4.
+	Concurrency int
+}
+
+// ServiceIncidentCount is the number of incidents a service opened in a
+// window, compared with the window of the same length just before it.
+type ServiceIncidentCount struct {
+	ServiceID     string
+	ServiceName   string
+	Count         int
+	PreviousCount int
+
+	// Delta is Count minus PreviousCount.
+	Delta int
+}
+
+// FetchServiceIncidentCountsOutput is the output of FetchServiceIncidentCountsActivity.
+type FetchServiceIncidentCountsOutput struct {
+	Document      transform.Document
+	Counts        []ServiceIncidentCount
+	Total         int
+	PreviousTotal int
+}
+
+// FetchServiceIncidentCountsActivity counts incidents per service over a window
+// and returns them ranked from noisiest to quietest, along with a summary
+// document. Each count is diffed against the previous window of the same
+// length. Services with no incidents are included with a zero count.
+func FetchServiceIncidentCountsActivity(ctx context.Context, input FetchServiceIncidentCountsInput) (FetchServiceIncidentCountsOutput, error) {
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey: input.APIKey,
+	})
+	if err != nil {
+		return FetchServiceIncidentCountsOutput{}, err
+	}
+
+	until := time.Now().UTC()
+	if input.Until != nil {
+		until = *input.Until
+	}
+
+	window := input.Window
+	if window <= 0 {
+		window = defaultCountWindow
+	}
+
+	since := until.Add(-window)
+	if input.Since != nil {
+		since = *input.Since
+	}
+
+	var services []Service
+	for offset := 0; ; {
+		page, err := client.ListServices(ctx, input.TeamIDs, 100, offset)
+		if err != nil {
+			return FetchServiceIncidentCountsOutput{}, fmt.Errorf("list services: %w", err)
+		}
+		services = append(services, page.Services...)
+		if !page.More || len(page.Services) == 0 {
+			break
+		}
+		offset += len(page.Services)
+	}
+
+	previousSince := since.Add(-until.Sub(since))
+
+	counts := make([]ServiceIncidentCount, len(services))
+	errs := make([]error, len(services))
+	runBounded(len(services), input.Concurrency, func(i int) {
+		counts[i], errs[i] = countServiceIncidents(ctx, client, services[i], previousSince, since, until)
+	})
+
+	var total, previousTotal int
+	for i, count := range counts {
+		if errs[i] != nil {
+			return FetchServiceIncidentCountsOutput{}, fmt.Errorf("count incidents for service %s: %w", services[i].ID, errs[i])
+		}
+		total += count.Count
+		previousTotal += count.PreviousCount
+	}
+
+	sortServiceIncidentCounts(counts)
+
+	return FetchServiceIncidentCountsOutput{
+		Document:      serviceIncidentCountsToDocument(counts, total, previousTotal, since, until),
+		Counts:        counts,
+		Total:         total,
+		PreviousTotal: previousTotal,
+	}, nil
+}
+
+// countServiceIncidents counts a service's incidents in [since, until) and
+// in the previous window [previousSince, since).
+func countServiceIncidents(ctx context.Context, client *Client, svc Service, previousSince, since, until time.Time) (ServiceIncidentCount, error) {
+	count, err := countIncidents(ctx, client, svc.ID, since, until)
+	if err != nil {
+		return ServiceIncidentCount{}, err
+	}
+	previous, err := countIncidents(ctx, client, svc.ID, previousSince, since)
+	if err != nil {
+		return ServiceIncidentCount{}, fmt.Errorf("previous window: %w", err)
+	}
+
+	return ServiceIncidentCount{
+		ServiceID:     svc.ID,
+		ServiceName:   svc.Name,
+		Count:         count,
+		PreviousCount: previous,
+		Delta:         count - previous,
+	}, nil
+}
+
+// countIncidents asks PagerDuty for the number of a service's incidents
+// created between since and until, without listing them.
+func countIncidents(ctx context.Context, client *Client, serviceID string, since, until time.Time) (int, error) {
+	result, err := client.ListIncidentsWithOptions(ctx, ListIncidentsOptions{
+		Since:      &since,
+		Until:      &until,
+		Limit:      1,
+		ServiceIDs: []string{serviceID},
+		Total:      true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.Total, nil
+}
+
+// sortServiceIncidentCounts orders counts from most to fewest incidents,
+// breaking ties by service name and then ID so the order is stable.
+func sortServiceIncidentCounts(counts []ServiceIncidentCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		if counts[i].ServiceName != counts[j].ServiceName {
+			return counts[i].ServiceName < counts[j].ServiceName
+		}
+		return counts[i].ServiceID < counts[j].ServiceID
+	})
+}
+
+func serviceIncidentCountsToDocument(counts []ServiceIncidentCount, total, previousTotal int, since, until time.Time) transform.Document {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Incidents by service from %s to %s", since.Format(time.RFC3339), until.Format(time.RFC3339)))
+
+	for i, count := range counts {
+		lines = append(lines, fmt.Sprintf("%d. %s: %d (%+d)", i+1, count.ServiceName, count.Count, count.Delta))
+	}
+
+	metadata := map[string]string{
+		"document_type":            "service_incident_counts",
+		"window_start":             since.Format(time.RFC3339),
+		"window_end":               until.Format(time.RFC3339),
+		"service_count":            strconv.Itoa(len(counts)),
+		"total_incidents":          strconv.Itoa(total),
+		"previous_total_incidents": strconv.Itoa(previousTotal),
+	}
+
+	if len(counts) > 0 {
+		metadata["noisiest_service"] = counts[0].ServiceName
+	}
+
+	return transform.Document{
+		ID:        fmt.Sprintf("service-incident-counts-%d-%d", since.Unix(), until.Unix()),
+		Content:   strings.Join(lines, "\n"),
+		Title:     "Incidents by service",
+		Source:    "pagerduty",
+		Metadata:  metadata,
+		UpdatedAt: until,
+	}
+}
+
+// FetchServiceIncidentCounts creates a node for counting incidents per PagerDuty service.
+func FetchServiceIncidentCounts(input FetchServiceIncidentCountsInput) *core.Node[FetchServiceIncidentCountsInput, FetchServiceIncidentCountsOutput] {
+	return core.NewNode("pagerduty.FetchServiceIncidentCounts", FetchServiceIncidentCountsActivity, input)
+}
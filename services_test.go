@@ -0,0 +1,84 @@
+package pagerduty
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFetchServiceIncidentCountsActivity_RanksAndDiffsServices(t *testing.T) {
+	// given
+	until := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	since := until.Add(-7 * 24 * time.Hour)
+	current := map[string]int{"PS1": 3, "PS2": 0, "PS3": 3, "PS4": 5}
+	previous := map[string]int{"PS1": 1, "PS3": 4}
+	useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/services" {
+			writeJSON(t, w, ServiceListResponse{Services: []Service{
+				{ID: "PS1", Name: "api"},
+				{ID: "PS2", Name: "billing"},
+				{ID: "PS3", Name: "cache"},
+				{ID: "PS4", Name: "auth"},
+			}})
+			return
+		}
+
+		counts := previous
+		if r.URL.Query().Get("since") == since.Format(time.RFC3339) {
+			counts = current
+		}
+		writeJSON(t, w, IncidentListResponse{Total: counts[r.URL.Query().Get("service_ids[]")]})
+	}))
+
+	// when
+	output, err := FetchServiceIncidentCountsActivity(context.Background(), FetchServiceIncidentCountsInput{
+		Until:  &until,
+		Window: 7 * 24 * time.Hour,
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []ServiceIncidentCount{
+		{ServiceID: "PS4", ServiceName: "auth", Count: 5, PreviousCount: 0, Delta: 5},
+		{ServiceID: "PS1", ServiceName: "api", Count: 3, PreviousCount: 1, Delta: 2},
+		{ServiceID: "PS3", ServiceName: "cache", Count: 3, PreviousCount: 4, Delta: -1},
+		{ServiceID: "PS2", ServiceName: "billing", Count: 0, PreviousCount: 0, Delta: 0},
+	}
+	if !slices.Equal(output.Counts, want) {
+		t.Errorf("got counts %+v, want %+v", output.Counts, want)
+	}
+	if output.Total != 11 || output.PreviousTotal != 5 {
+		t.Errorf("got totals %d and %d, want 11 and 5", output.Total, output.PreviousTotal)
+	}
+	if got := output.Document.Metadata["noisiest_service"]; got != "auth" {
+		t.Errorf("got noisiest_service %q, want auth", got)
+	}
+}
+
+func TestSortServiceIncidentCounts_StableForTies(t *testing.T) {
+	t.Parallel()
+
+	// given
+	counts := []ServiceIncidentCount{
+		{ServiceID: "PS3", ServiceName: "web", Count: 2},
+		{ServiceID: "PS2", ServiceName: "api", Count: 2},
+		{ServiceID: "PS1", ServiceName: "api", Count: 2},
+		{ServiceID: "PS4", ServiceName: "db", Count: 0},
+	}
+
+	// when
+	sortServiceIncidentCounts(counts)
+
+	// then
+	var ids []string
+	for _, count := range counts {
+		ids = append(ids, count.ServiceID)
+	}
+	if want := []string{"PS1", "PS2", "PS3", "PS4"}; !slices.Equal(ids, want) {
+		t.Errorf("got order %v, want %v", ids, want)
+	}
+}
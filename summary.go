@@ -0,0 +1,125 @@
+package pagerduty
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	transform "github.com/resolute-sh/resolute-transform"
+)
+
+// incidentSummaryToDocument summarizes a set of incidents: counts by
+// status, urgency and service, and the mean time to resolve for those that
+// are resolved. The window is since/until when given, otherwise the span of
+// the incidents' creation times.
+func incidentSummaryToDocument(incidents []Incident, since, until *time.Time) transform.Document {
+	byStatus := make(map[string]int)
+	byUrgency := make(map[string]int)
+	byService := make(map[string]int)
+
+	var start, end time.Time
+	var resolved int
+	var resolveTime time.Duration
+	for _, incident := range incidents {
+		byStatus[incident.Status]++
+		byUrgency[incident.Urgency]++
+
+		service := incident.Service.Name
+		if service == "" {
+			service = incident.Service.Summary
+		}
+		byService[service]++
+
+		if start.IsZero() || incident.CreatedAt.Before(start) {
+			start = incident.CreatedAt
+		}
+		if incident.CreatedAt.After(end) {
+			end = incident.CreatedAt
+		}
+
+		if incident.ResolvedAt != nil {
+			resolved++
+			resolveTime += incident.ResolvedAt.Sub(incident.CreatedAt)
+		}
+	}
+	if since != nil {
+		start = *since
+	}
+	if until != nil {
+		end = *until
+	}
+
+	metadata := map[string]string{
+		"document_type":  "incident_summary",
+		"incident_count": strconv.Itoa(len(incidents)),
+		"resolved_count": strconv.Itoa(resolved),
+	}
+	if !start.IsZero() {
+		metadata["window_start"] = start.Format(time.RFC3339)
+	}
+	if !end.IsZero() {
+		metadata["window_end"] = end.Format(time.RFC3339)
+	}
+
+	lines := []string{
+		fmt.Sprintf("%d incidents from %s to %s", len(incidents), formatWindowTime(start), formatWindowTime(end)),
+	}
+
+	if resolved > 0 {
+		mttr := resolveTime / time.Duration(resolved)
+		metadata["mttr_seconds"] = strconv.FormatInt(int64(mttr.Seconds()), 10)
+		lines = append(lines, fmt.Sprintf("Mean time to resolve: %s (%d resolved)", mttr.Round(time.Second), resolved))
+	}
+
+	for status, n := range byStatus {
+		metadata["status_"+status] = strconv.Itoa(n)
+	}
+	for urgency, n := range byUrgency {
+		metadata["urgency_"+urgency] = strconv.Itoa(n)
+	}
+
+	lines = append(lines, "", "By status:")
+	lines = append(lines, countLines(byStatus)...)
+	lines = append(lines, "", "By urgency:")
+	lines = append(lines, countLines(byUrgency)...)
+	lines = append(lines, "", "By service:")
+	lines = append(lines, countLines(byService)...)
+
+	return transform.Document{
+		ID:        fmt.Sprintf("incident-summary-%d-%d", start.Unix(), end.Unix()),
+		Content:   strings.Join(lines, "\n"),
+		Title:     "Incident summary",
+		Source:    "pagerduty",
+		Metadata:  metadata,
+		UpdatedAt: end,
+	}
+}
+
+// countLines renders counts from most to least frequent, then by name.
+func countLines(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("- %s: %d", k, counts[k]))
+	}
+	return lines
+}
+
+func formatWindowTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format(time.RFC3339)
+}
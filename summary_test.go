@@ -0,0 +1,87 @@
+package pagerduty
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchIncidentsActivity_SummaryMatchesFetchedIncidents(t *testing.T) {
+	// given
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+	created := since.Add(time.Hour)
+	resolvedFast := created.Add(30 * time.Minute)
+	resolvedSlow := created.Add(90 * time.Minute)
+	useTestAPI(t, listIncidents(t,
+		Incident{ID: "P1", Status: StatusResolved, Urgency: "high", CreatedAt: created, ResolvedAt: &resolvedFast, Service: Service{Name: "API"}},
+		Incident{ID: "P2", Status: StatusResolved, Urgency: "low", CreatedAt: created, ResolvedAt: &resolvedSlow, Service: Service{Name: "API"}},
+		Incident{ID: "P3", Status: StatusTriggered, Urgency: "high", CreatedAt: created, Service: Service{Name: "DB"}},
+	))
+
+	// when
+	output, docs := fetchIncidentDocs(t, FetchIncidentsInput{
+		Since:       &since,
+		Until:       &until,
+		EmitSummary: true,
+	})
+
+	// then
+	if len(docs) != 3 {
+		t.Errorf("got %d incident documents, want 3", len(docs))
+	}
+
+	summaries := loadDocs(t, output.SummaryRef)
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summary documents, want 1", len(summaries))
+	}
+	summary := summaries[0]
+
+	want := map[string]string{
+		"document_type":    "incident_summary",
+		"incident_count":   "3",
+		"resolved_count":   "2",
+		"mttr_seconds":     "3600",
+		"status_resolved":  "2",
+		"status_triggered": "1",
+		"urgency_high":     "2",
+		"urgency_low":      "1",
+		"window_start":     "2024-01-01T00:00:00Z",
+		"window_end":       "2024-01-02T00:00:00Z",
+	}
+	for key, value := range want {
+		if got := summary.Metadata[key]; got != value {
+			t.Errorf("got %s %q, want %q", key, got, value)
+		}
+	}
+	for _, line := range []string{"Mean time to resolve: 1h0m0s (2 resolved)", "- API: 2", "- DB: 1"} {
+		if !strings.Contains(summary.Content, line) {
+			t.Errorf("summary content lacks %q:\n%s", line, summary.Content)
+		}
+	}
+}
+
+func TestIncidentSummaryToDocument_CountsServiceReferencesBySummary(t *testing.T) {
+	t.Parallel()
+
+	// given
+	created := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	incidents := []Incident{
+		{ID: "P1", Status: StatusTriggered, Urgency: "high", CreatedAt: created, Service: Service{ID: "PS1", Type: "service_reference", Summary: "API"}},
+		{ID: "P2", Status: StatusTriggered, Urgency: "high", CreatedAt: created, Service: Service{ID: "PS1", Type: "service_reference", Summary: "API"}},
+		{ID: "P3", Status: StatusTriggered, Urgency: "high", CreatedAt: created, Service: Service{ID: "PS2", Type: "service_reference", Summary: "DB"}},
+	}
+
+	// when
+	doc := incidentSummaryToDocument(incidents, nil, nil)
+
+	// then
+	for _, line := range []string{"- API: 2", "- DB: 1"} {
+		if !strings.Contains(doc.Content, line) {
+			t.Errorf("summary content lacks %q:\n%s", line, doc.Content)
+		}
+	}
+	if strings.Contains(doc.Content, "- : ") {
+		t.Errorf("summary content has an unnamed service:\n%s", doc.Content)
+	}
+}
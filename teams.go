@@ -0,0 +1,153 @@
+package pagerduty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	transform "github.com/resolute-sh/resolute-transform"
+	"github.com/resolute-sh/resolute/core"
+)
+
+// FetchTeamIncidentsInput is the input for FetchTeamIncidentsActivity.
+type FetchTeamIncidentsInput struct {
+	APIKey    string
+	SecretRef string
+	TeamIDs   []string
+	Since     *time.Time
+	Until     *time.Time
+	Statuses  []string
+
+	// Limit bounds how many incidents are fetched per team. Defaults to 100.
+	Limit int
+
+	// Concurrency bounds how many teams are fetched at once. Defaults to 4.
+	// Requests from every team still go through the rate limiter set with
+	// SetRateLimiter.
+	Concurrency int
+}
+
+// TeamFailure records a team a multi-team fetch failed on.
+type TeamFailure struct {
+	TeamID string
+	Error  string
+}
+
+// FetchTeamIncidentsOutput is the output of FetchTeamIncidentsActivity.
+type FetchTeamIncidentsOutput struct {
+	Ref   core.DataRef
+	Count int
+
+	// Counts maps each team that was fetched successfully to its number of
+	// documents.
+	Counts map[string]int
+	Failed []TeamFailure
+}
+
+// FetchTeamIncidentsActivity fetches incidents for each of several teams
+// concurrently and stores them together, labelling each document with the
+// team_id it was fetched for. Document IDs are prefixed with the team ID,
+// e.g. "PTEAM1:PINC1", so an incident owned by several of the teams is
+// stored once per team without the copies overwriting each other. Teams
+// that fail are reported in Failed while the others are still stored; the
+// activity only fails if every team does.
+func FetchTeamIncidentsActivity(ctx context.Context, input FetchTeamIncidentsInput) (FetchTeamIncidentsOutput, error) {
+	if len(input.TeamIDs) == 0 {
+		return FetchTeamIncidentsOutput{}, errors.New("at least one team ID is required")
+	}
+
+	client, err := activityClient(ctx, input.SecretRef, ClientConfig{
+		APIKey: input.APIKey,
+	})
+	if err != nil {
+		return FetchTeamIncidentsOutput{}, err
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	incidents := make([][]Incident, len(input.TeamIDs))
+	errs := make([]error, len(input.TeamIDs))
+	runBounded(len(input.TeamIDs), input.Concurrency, func(i int) {
+		incidents[i], errs[i] = listTeamIncidents(ctx, client, input, input.TeamIDs[i], limit)
+	})
+
+	output := FetchTeamIncidentsOutput{
+		Counts: make(map[string]int, len(input.TeamIDs)),
+	}
+
+	var docs []transform.Document
+	for i, teamID := range input.TeamIDs {
+		if errs[i] != nil {
+			output.Failed = append(output.Failed, TeamFailure{
+				TeamID: teamID,
+				Error:  errs[i].Error(),
+			})
+			continue
+		}
+
+		sortIncidents(incidents[i])
+		for _, incident := range incidents[i] {
+			doc := incidentToDocument(incident)
+			doc.ID = teamID + ":" + doc.ID
+			doc.Metadata["team_id"] = teamID
+			docs = append(docs, doc)
+		}
+		output.Counts[teamID] = len(incidents[i])
+	}
+
+	if len(output.Failed) == len(input.TeamIDs) {
+		return FetchTeamIncidentsOutput{}, fmt.Errorf("fetch incidents for all %d teams: %w", len(input.TeamIDs), errors.Join(errs...))
+	}
+
+	if err := postProcessDocuments(ctx, docs); err != nil {
+		return FetchTeamIncidentsOutput{}, err
+	}
+
+	ref, err := transform.StoreDocuments(ctx, docs)
+	if err != nil {
+		return FetchTeamIncidentsOutput{}, fmt.Errorf("store documents: %w", err)
+	}
+	output.Ref = ref
+	output.Count = len(docs)
+
+	return output, nil
+}
+
+// listTeamIncidents pages through up to limit incidents for one team.
+func listTeamIncidents(ctx context.Context, client *Client, input FetchTeamIncidentsInput, teamID string, limit int) ([]Incident, error) {
+	var incidents []Incident
+	for len(incidents) < limit {
+		pageSize := limit - len(incidents)
+		if pageSize > 100 {
+			pageSize = 100
+		}
+
+		result, err := client.ListIncidentsWithOptions(ctx, ListIncidentsOptions{
+			Since:    input.Since,
+			Until:    input.Until,
+			Limit:    pageSize,
+			Offset:   len(incidents),
+			Statuses: input.Statuses,
+			TeamIDs:  []string{teamID},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list incidents for team %s: %w", teamID, err)
+		}
+
+		incidents = append(incidents, result.Incidents...)
+		if !result.More || len(result.Incidents) == 0 {
+			break
+		}
+	}
+	return incidents, nil
+}
+
+// FetchTeamIncidents creates a node for fetching PagerDuty incidents across
+// several teams.
+func FetchTeamIncidents(input FetchTeamIncidentsInput) *core.Node[FetchTeamIncidentsInput, FetchTeamIncidentsOutput] {
+	return core.NewNode("pagerduty.FetchTeamIncidents", FetchTeamIncidentsActivity, input)
+}
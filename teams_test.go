@@ -0,0 +1,46 @@
+package pagerduty
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestFetchTeamIncidentsActivity_SharedIncidentStoredPerTeam(t *testing.T) {
+	// given
+	byTeam := map[string][]Incident{
+		"T1": {{ID: "P1", Summary: "shared outage"}},
+		"T2": {{ID: "P1", Summary: "shared outage"}, {ID: "P2", Summary: "team two only"}},
+	}
+	useTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, IncidentListResponse{Incidents: byTeam[r.URL.Query().Get("team_ids[]")]})
+	}))
+
+	// when
+	output, err := FetchTeamIncidentsActivity(context.Background(), FetchTeamIncidentsInput{
+		TeamIDs: []string{"T1", "T2"},
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Counts["T1"] != 1 || output.Counts["T2"] != 2 {
+		t.Errorf("got counts %v, want T1=1 T2=2", output.Counts)
+	}
+
+	docs := docsByID(loadDocs(t, output.Ref))
+	if len(docs) != 3 {
+		t.Fatalf("got %d distinct documents, want 3", len(docs))
+	}
+	for id, team := range map[string]string{"T1:P1": "T1", "T2:P1": "T2", "T2:P2": "T2"} {
+		doc, ok := docs[id]
+		if !ok {
+			t.Errorf("missing document %s", id)
+			continue
+		}
+		if doc.Metadata["team_id"] != team {
+			t.Errorf("document %s has team_id %q, want %q", id, doc.Metadata["team_id"], team)
+		}
+	}
+}